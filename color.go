@@ -1,7 +1,13 @@
 package utl
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gookit/color"
 )
@@ -25,23 +31,355 @@ var (
 	Mag2 = color.FgMagenta.Render
 )
 
-// PrintColorSamples is a dormant function and not actively used anywhere. It is left
-// around for those times when someone wants to test and see what the colors would look like.
+// Prints the named color variables, the 16 basic colors, the 256-color palette, a
+// truecolor gradient, the text styles, and a sample of the active theme. Handy for seeing
+// what the terminal makes of them. Colors are downgraded as the terminal requires.
 func PrintColorSamples() {
-	fmt.Println("Red  " + Red("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Blu  " + Blu("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Gre  " + Gre("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Yel  " + Yel("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Whi  " + Whi("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Cya  " + Cya("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Mag  " + Mag("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Gra  " + Gra("!@#$%#$%&#%*^2314897123589"))
-
-	fmt.Println("Red2 " + Red2("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Blu2 " + Blu2("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Gre2 " + Gre2("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Yel2 " + Yel2("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Whi2 " + Whi2("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Cya2 " + Cya2("!@#$%#$%&#%*^2314897123589"))
-	fmt.Println("Mag2 " + Mag2("!@#$%#$%&#%*^2314897123589"))
+	named := []struct {
+		name  string
+		style StyleFunc
+	}{
+		{"Red", Red}, {"Blu", Blu}, {"Gre", Gre}, {"Yel", Yel}, {"Whi", Whi}, {"Cya", Cya},
+		{"Mag", Mag}, {"Gra", Gra}, {"Red2", Red2}, {"Blu2", Blu2}, {"Gre2", Gre2},
+		{"Yel2", Yel2}, {"Whi2", Whi2}, {"Cya2", Cya2}, {"Mag2", Mag2},
+	}
+	fmt.Println(Gra("Named colors"))
+	for i, n := range named {
+		fmt.Print(n.style(fmt.Sprintf("%-6s", n.name)))
+		if i%8 == 7 || i == len(named)-1 {
+			fmt.Println()
+		}
+	}
+
+	// Number in black or white, whichever reads better on the swatch
+	swatch := func(c TermColor, label int, width int) string {
+		r, g, b := c.RGB()
+		fg := Ansi(15)
+		if 299*int(r)+587*int(g)+114*int(b) > 128000 {
+			fg = Ansi(0)
+		}
+		return NewStyle().Fg(fg).Bg(c).Render(fmt.Sprintf("%*d ", width, label))
+	}
+	fmt.Println("\n" + Gra("16 colors"))
+	for i := 0; i < 16; i++ {
+		fmt.Print(swatch(Ansi(uint8(i)), i, 3))
+		if i%8 == 7 {
+			fmt.Println()
+		}
+	}
+	fmt.Println("\n" + Gra("256 colors"))
+	for i := 16; i < 232; i++ {
+		fmt.Print(swatch(Color256(uint8(i)), i, 3))
+		if (i-16)%18 == 17 {
+			fmt.Println()
+		}
+	}
+	for i := 232; i < 256; i++ {
+		fmt.Print(swatch(Color256(uint8(i)), i, 3))
+		if (i-232)%12 == 11 {
+			fmt.Println()
+		}
+	}
+	fmt.Println("\n" + Gra("Truecolor"))
+	const steps = 72
+	for i := 0; i < steps; i++ {
+		r, g, b := hueToRgb(float64(i) / steps)
+		fmt.Print(NewStyle().Bg(RGB(r, g, b)).Render(" "))
+	}
+	fmt.Println()
+
+	fmt.Println("\n" + Gra("Styles"))
+	plain := NewStyle()
+	fmt.Println(plain.Bold().Render("Bold") + "  " + plain.Dim().Render("Dim") + "  " +
+		plain.Italic().Render("Italic") + "  " + plain.Underline().Render("Underline"))
+
+	t := activeTheme
+	fmt.Println("\n" + Gra("Theme "+t.Name))
+	sample := t.Key("Key") + " " + t.String("String") + " " + t.Number("Number") + " " +
+		t.Bool("Bool") + " " + t.Null("Null") + " " + t.Anchor("Anchor") + " " +
+		t.Comment("Comment") + " " + t.Document("Document") + " " + t.Plain("Plain") + " " +
+		t.LineNumber("LineNumber") + " " + t.Highlight("Highlight") + " " + t.Error("Error")
+	fmt.Println(downgradeANSI(sample, colorLevelFor(os.Stdout)))
+}
+
+// Returns the fully saturated color at given hue, from 0 to 1. Internal helper function.
+func hueToRgb(hue float64) (r, g, b uint8) {
+	h := hue * 6
+	x := uint8(255 * (1 - math.Abs(math.Mod(h, 2)-1)))
+	switch int(h) % 6 {
+	case 0:
+		return 255, x, 0
+	case 1:
+		return x, 255, 0
+	case 2:
+		return 0, 255, x
+	case 3:
+		return 0, x, 255
+	case 4:
+		return x, 0, 255
+	}
+	return 255, 0, x
+}
+
+// StyleFunc renders its arguments, like fmt.Sprint, wrapped in a color or text style.
+// The color variables above, like Red, all have this signature.
+type StyleFunc func(a ...interface{}) string
+
+// Theme is the set of styles the color printers use for each kind of token. The styles
+// should always emit their escape codes, like Style.RenderAlways does, as the printers
+// remove or downgrade them to suit each writer.
+type Theme struct {
+	Name       string
+	Key        StyleFunc
+	String     StyleFunc
+	Number     StyleFunc
+	Bool       StyleFunc
+	Null       StyleFunc
+	Anchor     StyleFunc // YAML anchors, aliases and tags
+	Comment    StyleFunc
+	Document   StyleFunc // YAML "---" and "..." markers
+	Plain      StyleFunc // Punctuation and everything else
+	LineNumber StyleFunc
+	Highlight  StyleFunc // Gutter of highlighted lines
+	Error      StyleFunc // Position of a syntax error
+}
+
+var (
+	// The original palette, for terminals with a dark background
+	ThemeDark = &Theme{
+		Name:       "dark",
+		Key:        codeStyle(color.FgLightBlue),
+		String:     codeStyle(color.FgGreen),
+		Number:     codeStyle(color.FgLightMagenta),
+		Bool:       codeStyle(color.FgLightMagenta),
+		Null:       codeStyle(color.FgWhite),
+		Anchor:     codeStyle(color.FgYellow),
+		Comment:    codeStyle(color.FgWhite),
+		Document:   codeStyle(color.FgCyan),
+		Plain:      codeStyle(color.FgWhite),
+		LineNumber: codeStyle(color.FgDarkGray),
+		Highlight:  codeStyle(color.FgLightYellow),
+		Error:      codeStyle(color.New(color.FgLightWhite, color.BgRed)),
+	}
+
+	// Darker colors, readable on a light background
+	ThemeLight = &Theme{
+		Name:       "light",
+		Key:        codeStyle(color.FgBlue),
+		String:     codeStyle(color.FgGreen),
+		Number:     codeStyle(color.FgMagenta),
+		Bool:       codeStyle(color.FgMagenta),
+		Null:       codeStyle(color.FgBlack),
+		Anchor:     codeStyle(color.FgRed),
+		Comment:    codeStyle(color.FgDarkGray),
+		Document:   codeStyle(color.FgCyan),
+		Plain:      codeStyle(color.FgBlack),
+		LineNumber: codeStyle(color.FgDarkGray),
+		Highlight:  codeStyle(color.New(color.FgRed, color.OpBold)),
+		Error:      codeStyle(color.New(color.FgLightWhite, color.BgRed)),
+	}
+
+	// Ethan Schoonover's Solarized, downgraded as the terminal requires
+	ThemeSolarized = &Theme{
+		Name:       "solarized",
+		Key:        NewStyle().Fg(MustHex("#268bd2")).RenderAlways, // blue
+		String:     NewStyle().Fg(MustHex("#859900")).RenderAlways, // green
+		Number:     NewStyle().Fg(MustHex("#d33682")).RenderAlways, // magenta
+		Bool:       NewStyle().Fg(MustHex("#6c71c4")).RenderAlways, // violet
+		Null:       NewStyle().Fg(MustHex("#dc322f")).RenderAlways, // red
+		Anchor:     NewStyle().Fg(MustHex("#b58900")).RenderAlways, // yellow
+		Comment:    NewStyle().Fg(MustHex("#586e75")).RenderAlways, // base01
+		Document:   NewStyle().Fg(MustHex("#2aa198")).RenderAlways, // cyan
+		Plain:      NewStyle().Fg(MustHex("#839496")).RenderAlways, // base0
+		LineNumber: NewStyle().Fg(MustHex("#586e75")).RenderAlways, // base01
+		Highlight:  NewStyle().Fg(MustHex("#cb4b16")).RenderAlways, // orange
+		Error:      NewStyle().Fg(MustHex("#fdf6e3")).Bg(MustHex("#dc322f")).RenderAlways,
+	}
+
+	// No colors at all, only bold keys, italic nulls and dim comments
+	ThemeMonochrome = &Theme{
+		Name:       "monochrome",
+		Key:        codeStyle(color.OpBold),
+		String:     fmt.Sprint,
+		Number:     fmt.Sprint,
+		Bool:       fmt.Sprint,
+		Null:       codeStyle(color.OpItalic),
+		Anchor:     fmt.Sprint,
+		Comment:    codeStyle(color.OpFuzzy),
+		Document:   codeStyle(color.OpBold),
+		Plain:      fmt.Sprint,
+		LineNumber: codeStyle(color.OpFuzzy),
+		Highlight:  codeStyle(color.OpReverse),
+		Error:      codeStyle(color.New(color.OpReverse, color.OpBold)),
+	}
+)
+
+// Returns a StyleFunc wrapping its arguments in given gookit/color color or style
+// whatever the color mode, for the built-in themes. Internal helper function.
+func codeStyle(c interface{ Code() string }) StyleFunc {
+	code := c.Code()
+	return func(a ...interface{}) string {
+		text := fmt.Sprint(a...)
+		if text == "" {
+			return text
+		}
+		return "\x1b[" + code + "m" + text + "\x1b[0m"
+	}
+}
+
+var activeTheme = ThemeDark
+
+// Makes given theme the one used by printers not given one explicitly. Nil restores
+// ThemeDark.
+func SetTheme(theme *Theme) {
+	if theme == nil {
+		theme = ThemeDark
+	}
+	activeTheme = theme
+}
+
+// Returns the theme used by printers not given one explicitly
+func ActiveTheme() *Theme {
+	return activeTheme
+}
+
+// Returns the built-in theme with given name, "dark", "light", "solarized" or
+// "monochrome", or nil if there's no such theme.
+func ThemeByName(name string) *Theme {
+	for _, theme := range []*Theme{ThemeDark, ThemeLight, ThemeSolarized, ThemeMonochrome} {
+		if strings.EqualFold(theme.Name, name) {
+			return theme
+		}
+	}
+	return nil
+}
+
+// PrintOption tweaks how the color printers, like PrintYamlBytesColor, print. Without
+// any, they print to stdout with the active theme, without line numbers.
+type PrintOption func(*printOptions)
+
+type printOptions struct {
+	writer         io.Writer
+	theme          *Theme
+	lineNumbers    bool
+	highlightStart int        // 1-based, 0 for none
+	highlightEnd   int        // Inclusive
+	level          ColorLevel // Colors the writer takes under the current ColorMode
+}
+
+// Print to given writer instead of stdout
+func WithWriter(w io.Writer) PrintOption {
+	return func(o *printOptions) { o.writer = w }
+}
+
+// Print with given theme instead of the active one, see SetTheme
+func WithTheme(theme *Theme) PrintOption {
+	return func(o *printOptions) { o.theme = theme }
+}
+
+// Print a line number before each line
+func WithLineNumbers() PrintOption {
+	return func(o *printOptions) { o.lineNumbers = true }
+}
+
+// Mark lines start through end, 1-based and inclusive, with a ">" and the theme's
+// Highlight style. An end before start highlights line start only.
+func WithHighlight(start, end int) PrintOption {
+	return func(o *printOptions) {
+		o.highlightStart = start
+		o.highlightEnd = end
+	}
+}
+
+// Returns the print options given by opts, with defaults filled in. Internal helper
+// function.
+func newPrintOptions(opts []PrintOption) printOptions {
+	var o printOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.writer == nil {
+		o.writer = os.Stdout
+	}
+	if o.theme == nil {
+		o.theme = activeTheme
+	}
+	if o.highlightStart < 0 {
+		o.highlightStart = 0
+	}
+	if o.highlightEnd < o.highlightStart {
+		o.highlightEnd = o.highlightStart
+	}
+	o.level = colorLevelFor(o.writer)
+	return o
+}
+
+// Writes given colorized lines to o.writer, each behind a gutter with its line number
+// and highlight marker, as o asks for. Returns write error, if any. Internal helper
+// function.
+func writeColorLines(lines []string, o printOptions) error {
+	w := newColorLineWriter(o, lineNumberWidth(len(lines)))
+	for _, line := range lines {
+		w.writeLine(line)
+	}
+	return w.flush()
+}
+
+// colorLineWriter writes colorized lines one at a time, behind the gutter the print
+// options ask for, so printers can stream their output.
+type colorLineWriter struct {
+	o     printOptions
+	w     *bufio.Writer
+	width int // Of the line numbers
+	n     int // Lines written so far
+	err   error
+}
+
+// Returns the width of a line number column that fits numbers up to maxLines, and at
+// least two digits. Internal helper function.
+func lineNumberWidth(maxLines int) int {
+	return max(len(strconv.Itoa(maxLines)), 2)
+}
+
+// Returns a colorLineWriter for o, with a line number column of given width. The column
+// doesn't change once lines are written, so numbers wider than it push their line right.
+func newColorLineWriter(o printOptions, width int) *colorLineWriter {
+	return &colorLineWriter{o: o, w: bufio.NewWriter(o.writer), width: width}
+}
+
+// Writes given line, which must not contain line breaks, plus its gutter
+func (lw *colorLineWriter) writeLine(line string) {
+	if lw.err != nil {
+		return
+	}
+	lw.n++
+	highlight := lw.o.highlightStart > 0
+	marked := highlight && lw.n >= lw.o.highlightStart && lw.n <= lw.o.highlightEnd
+	theme := lw.o.theme
+	var sb strings.Builder
+	if highlight {
+		if marked {
+			sb.WriteString(theme.Highlight(">") + " ")
+		} else {
+			sb.WriteString("  ")
+		}
+	}
+	if lw.o.lineNumbers {
+		num := fmt.Sprintf("%*d", lw.width, lw.n)
+		if marked {
+			sb.WriteString(theme.Highlight(num) + "  ")
+		} else {
+			sb.WriteString(theme.LineNumber(num) + "  ")
+		}
+	}
+	sb.WriteString(line)
+	_, lw.err = lw.w.WriteString(downgradeANSI(sb.String(), lw.o.level) + "\n")
+}
+
+// Flushes buffered output. Returns the first write error, if any.
+func (lw *colorLineWriter) flush() error {
+	if err := lw.w.Flush(); lw.err == nil {
+		lw.err = err
+	}
+	return lw.err
 }
@@ -0,0 +1,158 @@
+package utl
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/color"
+	"golang.org/x/term"
+)
+
+// ColorMode decides whether the color variables and printers emit ANSI escape codes
+type ColorMode int
+
+const (
+	// Color only when writing to a terminal, and the environment doesn't say otherwise.
+	// NO_COLOR turns colors off, FORCE_COLOR or CLICOLOR_FORCE turn them on even when
+	// not on a terminal, CLICOLOR=0 and TERM=dumb turn them off, in that order. The
+	// default.
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+var colorMode = ColorAuto
+
+func init() {
+	SetColorMode(ColorAuto)
+}
+
+// Sets whether the color variables, like Red, and the printers emit ANSI escape codes.
+// The default is ColorAuto, which decides per writer, so the printers can color a
+// terminal writer while stdout is redirected. An explicit ColorAlways or ColorNever,
+// typically from a --color flag, overrides the environment. Call it at startup, before
+// anything prints, as the color variables share gookit/color's global setting.
+func SetColorMode(mode ColorMode) {
+	colorMode = mode
+	color.Enable = colorEnabledFor(os.Stdout)
+	if color.Enable && color.TermColorLevel() == color.LevelNo {
+		// Not detected from TERM and friends, but asked for
+		color.ForceSetColorLevel(color.Level16)
+	}
+}
+
+// Returns the current color mode
+func GetColorMode() ColorMode {
+	return colorMode
+}
+
+// Returns true if output to stdout is currently colored
+func ColorEnabled() bool {
+	return color.Enable && color.SupportColor()
+}
+
+// Returns true if given file descriptor refers to a terminal
+func IsTerminal(fd uintptr) bool {
+	return term.IsTerminal(int(fd))
+}
+
+// Returns true if output to w should be colored under the current mode. Only an *os.File
+// can be a terminal. Internal helper function.
+func colorEnabledFor(w io.Writer) bool {
+	switch colorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if envFlagOn("FORCE_COLOR") || envFlagOn("CLICOLOR_FORCE") {
+		return true
+	}
+	if v, ok := os.LookupEnv("CLICOLOR"); ok && !envFlagOn("CLICOLOR") && v != "" {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && IsTerminal(f.Fd())
+}
+
+// Returns the color level to render at for output to w: ColorLevelNone if it gets no
+// colors, and otherwise the detected level, or 16 colors if none was detected.
+// Internal helper function.
+func colorLevelFor(w io.Writer) ColorLevel {
+	if !colorEnabledFor(w) {
+		return ColorLevelNone
+	}
+	return max(GetColorLevel(), ColorLevel16)
+}
+
+var sgrRegex = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+
+// Returns s with its escape codes fit for given color level: all removed for
+// ColorLevelNone, and 256 and 24-bit colors turned into the closest ones the level has.
+// Internal helper function.
+func downgradeANSI(s string, level ColorLevel) string {
+	if level == ColorLevelNone {
+		return StripANSI(s)
+	}
+	if level == ColorLevelTrue || !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	return sgrRegex.ReplaceAllStringFunc(s, func(seq string) string {
+		params := strings.Split(seq[2:len(seq)-1], ";")
+		out := make([]string, 0, len(params))
+		for i := 0; i < len(params); i++ {
+			p := params[i]
+			if (p != "38" && p != "48") || i+1 >= len(params) {
+				out = append(out, p)
+				continue
+			}
+			arg := func(j int) uint8 {
+				if i+j >= len(params) {
+					return 0
+				}
+				v, _ := strconv.Atoi(params[i+j])
+				return uint8(v)
+			}
+			var c TermColor
+			switch params[i+1] {
+			case "5":
+				c = Color256(arg(2))
+				i += 2
+			case "2":
+				c = RGB(arg(2), arg(3), arg(4))
+				i += 4
+			default:
+				out = append(out, p)
+				continue
+			}
+			out = append(out, c.code(level, p == "48"))
+		}
+		return "\x1b[" + strings.Join(out, ";") + "m"
+	})
+}
+
+// Returns a StyleFunc that renders with given style, but only as far as stdout currently
+// takes colors. For the theme's styles, which always emit their codes, when their output
+// goes straight to stdout. Internal helper function.
+func forStdout(style StyleFunc) StyleFunc {
+	level := colorLevelFor(os.Stdout)
+	return func(a ...interface{}) string {
+		return downgradeANSI(style(a...), level)
+	}
+}
+
+// Returns true if given environment variable is set to anything but empty, "0" or "false".
+// Internal helper function.
+func envFlagOn(name string) bool {
+	v := strings.ToLower(os.Getenv(name))
+	return v != "" && v != "0" && v != "false"
+}
@@ -0,0 +1,91 @@
+package utl
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestColorEnabledFor(t *testing.T) {
+	defer SetColorMode(GetColorMode())
+	for _, name := range []string{"NO_COLOR", "FORCE_COLOR", "CLICOLOR_FORCE", "CLICOLOR", "TERM"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	tests := []struct {
+		name string
+		mode ColorMode
+		env  map[string]string
+		want bool
+	}{
+		{"auto, not a terminal", ColorAuto, nil, false},
+		{"auto, forced", ColorAuto, map[string]string{"FORCE_COLOR": "1"}, true},
+		{"auto, forced by CLICOLOR_FORCE", ColorAuto, map[string]string{"CLICOLOR_FORCE": "yes"}, true},
+		{"auto, FORCE_COLOR=0", ColorAuto, map[string]string{"FORCE_COLOR": "0"}, false},
+		{"auto, NO_COLOR beats FORCE_COLOR", ColorAuto, map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"}, false},
+		{"auto, CLICOLOR=0", ColorAuto, map[string]string{"CLICOLOR": "0"}, false},
+		{"auto, TERM=dumb", ColorAuto, map[string]string{"TERM": "dumb", "CLICOLOR": "1"}, false},
+		{"auto, forced beats CLICOLOR=0", ColorAuto, map[string]string{"CLICOLOR": "0", "FORCE_COLOR": "1"}, true},
+		{"always", ColorAlways, map[string]string{"NO_COLOR": "1"}, true},
+		{"never", ColorNever, map[string]string{"FORCE_COLOR": "1"}, false},
+	}
+	for _, tt := range tests {
+		for k, v := range tt.env {
+			os.Setenv(k, v)
+		}
+		SetColorMode(tt.mode)
+		if got := colorEnabledFor(&bytes.Buffer{}); got != tt.want {
+			t.Errorf("%s: colorEnabledFor(buffer) = %t, want %t", tt.name, got, tt.want)
+		}
+		if got := ColorEnabled(); got != tt.want {
+			t.Errorf("%s: ColorEnabled() with stdout not a terminal = %t, want %t", tt.name, got, tt.want)
+		}
+		for k := range tt.env {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+func TestDowngradeANSI(t *testing.T) {
+	const text = "\x1b[1;38;2;255;0;0;48;5;21mx\x1b[0m \x1b[94my\x1b[0m"
+	tests := []struct {
+		level ColorLevel
+		want  string
+	}{
+		{ColorLevelNone, "x y"},
+		{ColorLevel16, "\x1b[1;91;44mx\x1b[0m \x1b[94my\x1b[0m"},
+		{ColorLevel256, "\x1b[1;38;5;196;48;5;21mx\x1b[0m \x1b[94my\x1b[0m"},
+		{ColorLevelTrue, text},
+	}
+	for _, tt := range tests {
+		if got := downgradeANSI(text, tt.level); got != tt.want {
+			t.Errorf("downgradeANSI(level %d) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestColorPrintersPerWriter(t *testing.T) {
+	defer SetColorMode(GetColorMode())
+	const src = "a: 1\n"
+	print := func() string {
+		var buf bytes.Buffer
+		if err := PrintYamlBytesColor([]byte(src), WithWriter(&buf), WithTheme(ThemeSolarized)); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	SetColorMode(ColorAuto)
+	t.Setenv("FORCE_COLOR", "")
+	if got := print(); got != src {
+		t.Errorf("auto mode, buffer writer: got %q, want it uncolored", got)
+	}
+	SetColorMode(ColorAlways)
+	if got := print(); got == src || StripANSI(got) != src {
+		t.Errorf("always mode: got %q, want %q colored", got, src)
+	}
+	SetColorMode(ColorNever)
+	if got := print(); got != src {
+		t.Errorf("never mode: got %q, want it uncolored", got)
+	}
+}
@@ -0,0 +1,173 @@
+package utl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// DiffKind says what happened to a value between the two objects given to DiffObjects
+type DiffKind string
+
+const (
+	DiffAdded   DiffKind = "added"
+	DiffRemoved DiffKind = "removed"
+	DiffChanged DiffKind = "changed"
+)
+
+// DiffEntry is a single difference found by DiffObjects. Path is a JSON Pointer, Old is
+// nil for DiffAdded entries, and New is nil for DiffRemoved ones.
+type DiffEntry struct {
+	Kind DiffKind
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// Returns the structural differences between object trees a and b, as produced by
+// LoadFileJson, LoadFileYaml and friends. Objects are compared key by key in sorted
+// order and arrays index by index. Numbers are compared by value, so a YAML int 3
+// equals a JSON float64 3. Entries are ordered so they can be applied in sequence,
+// which is what DiffToJsonPatch relies on. Objects may also be *OrderedMap or yaml.v3's
+// map[interface{}]interface{}, and show up as plain maps in the entries.
+func DiffObjects(a, b interface{}) []DiffEntry {
+	entries := []DiffEntry{}
+	diffValues(orderedToPlain(a), orderedToPlain(b), "", &entries)
+	return entries
+}
+
+// Recursive worker for DiffObjects. Internal helper function.
+func diffValues(a, b interface{}, path string, entries *[]DiffEntry) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, k := range SortObjStringKeys(av) {
+			childPath := path + "/" + jsonPointerEscape(k)
+			if _, found := bv[k]; !found {
+				*entries = append(*entries, DiffEntry{Kind: DiffRemoved, Path: childPath, Old: av[k]})
+				continue
+			}
+			diffValues(av[k], bv[k], childPath, entries)
+		}
+		for _, k := range SortObjStringKeys(bv) {
+			if _, found := av[k]; !found {
+				*entries = append(*entries, DiffEntry{Kind: DiffAdded, Path: path + "/" + jsonPointerEscape(k), New: bv[k]})
+			}
+		}
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			diffValues(av[i], bv[i], path+"/"+strconv.Itoa(i), entries)
+		}
+		for i := len(av) - 1; i >= len(bv); i-- { // Highest index first, so removals apply cleanly
+			*entries = append(*entries, DiffEntry{Kind: DiffRemoved, Path: path + "/" + strconv.Itoa(i), Old: av[i]})
+		}
+		for i := len(av); i < len(bv); i++ {
+			*entries = append(*entries, DiffEntry{Kind: DiffAdded, Path: path + "/" + strconv.Itoa(i), New: bv[i]})
+		}
+		return
+	}
+	if !valuesEqual(a, b) {
+		*entries = append(*entries, DiffEntry{Kind: DiffChanged, Path: path, Old: a, New: b})
+	}
+}
+
+// Returns true if a and b are deeply equal, treating all numeric types alike.
+// Internal helper function.
+func valuesEqual(a, b interface{}) bool {
+	af, aNum := toFloat64(a)
+	bf, bNum := toFloat64(b)
+	if aNum && bNum {
+		return af == bf
+	}
+	if aNum != bNum {
+		return false
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if w, found := bv[k]; !found || !valuesEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !valuesEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Converts given diff entries into an RFC 6902 JSON Patch document, as a decoded JSON
+// array of operation objects ready for PrintJson, SaveFileJson or ApplyJsonPatch.
+func DiffToJsonPatch(entries []DiffEntry) []interface{} {
+	patch := make([]interface{}, 0, len(entries))
+	for _, e := range entries {
+		op := map[string]interface{}{"path": e.Path}
+		switch e.Kind {
+		case DiffAdded:
+			op["op"] = "add"
+			op["value"] = e.New
+		case DiffRemoved:
+			op["op"] = "remove"
+		case DiffChanged:
+			op["op"] = "replace"
+			op["value"] = e.New
+		}
+		patch = append(patch, op)
+	}
+	return patch
+}
+
+// Prints given diff entries in color, unified diff style: removed values in red prefixed
+// with '-', added values in green prefixed with '+', each under a yellow path header.
+func PrintDiff(entries []DiffEntry) {
+	for _, e := range entries {
+		path := e.Path
+		if path == "" {
+			path = "/"
+		}
+		switch e.Kind {
+		case DiffAdded:
+			fmt.Println(Yel("+ " + path))
+			fmt.Println(Gre("+   " + diffValueText(e.New)))
+		case DiffRemoved:
+			fmt.Println(Yel("- " + path))
+			fmt.Println(Red("-   " + diffValueText(e.Old)))
+		case DiffChanged:
+			fmt.Println(Yel("~ " + path))
+			fmt.Println(Red("-   " + diffValueText(e.Old)))
+			fmt.Println(Gre("+   " + diffValueText(e.New)))
+		}
+	}
+}
+
+// Returns v as compact JSON text, or Go formatted if it can't be marshaled.
+// Internal helper function.
+func diffValueText(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
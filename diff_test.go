@@ -0,0 +1,58 @@
+package utl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffObjects(t *testing.T) {
+	tests := []struct {
+		name, a, b string
+		want       []DiffEntry
+	}{
+		{"equal", `{"a":[1,{"b":2}]}`, `{"a":[1,{"b":2}]}`, []DiffEntry{}},
+		{
+			"keys added, removed and changed",
+			`{"a":1,"b":2,"c":{"d":3}}`,
+			`{"b":2,"c":{"d":4},"e":5}`,
+			[]DiffEntry{
+				{Kind: DiffRemoved, Path: "/a", Old: 1.0},
+				{Kind: DiffChanged, Path: "/c/d", Old: 3.0, New: 4.0},
+				{Kind: DiffAdded, Path: "/e", New: 5.0},
+			},
+		},
+		{
+			"array shrinks, highest index first",
+			`[1,2,3,4]`, `[1,9]`,
+			[]DiffEntry{
+				{Kind: DiffChanged, Path: "/1", Old: 2.0, New: 9.0},
+				{Kind: DiffRemoved, Path: "/3", Old: 4.0},
+				{Kind: DiffRemoved, Path: "/2", Old: 3.0},
+			},
+		},
+		{"array grows", `[1]`, `[1,[2]]`, []DiffEntry{{Kind: DiffAdded, Path: "/1", New: []interface{}{2.0}}}},
+		{"type change", `{"a":{"b":1}}`, `{"a":[1]}`, []DiffEntry{{Kind: DiffChanged, Path: "/a", Old: map[string]interface{}{"b": 1.0}, New: []interface{}{1.0}}}},
+		{"escaped keys", `{"a/b":1}`, `{"a/b":2}`, []DiffEntry{{Kind: DiffChanged, Path: "/a~1b", Old: 1.0, New: 2.0}}},
+		{"root scalar", `1`, `"1"`, []DiffEntry{{Kind: DiffChanged, Path: "", Old: 1.0, New: "1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffObjects(mustJsonObj(t, tt.a), mustJsonObj(t, tt.b))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffObjectsAcrossFormats(t *testing.T) {
+	// A YAML spec against the JSON an API returned: ints equal floats, key order and map
+	// types don't matter
+	spec := NewOrderedMap()
+	spec.Set("replicas", 3)
+	spec.Set("labels", map[interface{}]interface{}{"app": "web"})
+	api := mustJsonObj(t, `{"labels":{"app":"web"},"replicas":3.0}`)
+	if got := DiffObjects(spec, api); len(got) != 0 {
+		t.Errorf("got %+v, want no differences", got)
+	}
+}
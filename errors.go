@@ -0,0 +1,49 @@
+package utl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Sentinel errors returned (wrapped) by the file, encoding and date helpers in this
+// package. Use errors.Is to tell them apart, i.e. errors.Is(err, utl.ErrFileNotFound)
+var (
+	ErrFileNotFound   = errors.New("file not found")
+	ErrFileRead       = errors.New("file read failed")
+	ErrFileWrite      = errors.New("file write failed")
+	ErrMarshal        = errors.New("marshal failed")
+	ErrUnmarshal      = errors.New("unmarshal failed")
+	ErrUnknownField   = errors.New("unknown field")
+	ErrUnknownFormat  = errors.New("unknown file format")
+	ErrInvalidPath    = errors.New("invalid path")
+	ErrPathNotFound   = errors.New("path not found")
+	ErrInvalidQuery   = errors.New("invalid query")
+	ErrInvalidPatch   = errors.New("invalid patch")
+	ErrTestFailed     = errors.New("test failed")
+	ErrAliasLimit     = errors.New("alias expansion limit exceeded")
+	ErrAliasCycle     = errors.New("alias cycle")
+	ErrInvalidNumber  = errors.New("invalid number")
+	ErrInvalidDate    = errors.New("invalid date")
+	ErrInvalidColor   = errors.New("invalid color")
+	ErrInvalidPattern = errors.New("invalid pattern")
+	ErrTooLarge       = errors.New("input too large")
+)
+
+// Wraps err with given sentinel so both remain reachable via errors.Is and errors.As.
+// Returns nil if err is nil. Internal helper function.
+func wrapErr(sentinel, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
+
+// Wraps an error from opening or reading a file, flagging missing files as
+// ErrFileNotFound and anything else as ErrFileRead. Internal helper function.
+func readErr(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return wrapErr(ErrFileNotFound, err)
+	}
+	return wrapErr(ErrFileRead, err)
+}
@@ -1,37 +1,249 @@
 package utl
 
 import (
+	"fmt"
+	"io"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 )
 
+// SaveOption tweaks how the Save* functions write their target file
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	perm          os.FileMode
+	explicitPerm  bool
+	preserveMode  bool
+	preserveOwner bool
+	backups       int
+}
+
+// Write the file with exactly given permission bits, regardless of the umask, instead of
+// the function's default, which the umask applies to
+func WithPerm(perm os.FileMode) SaveOption {
+	return func(o *saveOptions) {
+		o.perm = perm
+		o.explicitPerm = true
+	}
+}
+
+// Keep the permission bits of the file being replaced, if it exists
+func WithPreserveMode() SaveOption {
+	return func(o *saveOptions) { o.preserveMode = true }
+}
+
+// Keep the owner and group of the file being replaced, if it exists. Only honored
+// on Unix-like systems, and usually requires sufficient privileges.
+func WithPreserveOwner() SaveOption {
+	return func(o *saveOptions) { o.preserveOwner = true }
+}
+
+// Keep a copy of the file being replaced as filePath + ".bak", overwriting any older one.
+// Same as WithBackups(1).
+func WithBackup() SaveOption {
+	return WithBackups(1)
+}
+
+// Keep the last n versions of the file being replaced, newest first, as filePath + ".bak",
+// filePath + ".bak.1" and so on up to filePath + ".bak.<n-1>". Older ones are dropped.
+func WithBackups(n int) SaveOption {
+	return func(o *saveOptions) { o.backups = n }
+}
+
 // Read and recode given filePath as text byte slice.
 // Returns the byte slice and error if any.
 func LoadFileText(filePath string) (rawBytes []byte, err error) {
 	rawBytes, err = os.ReadFile(filePath)
 	if err != nil {
-		return nil, err
+		return nil, readErr(err)
 	}
 	return rawBytes, nil
 }
 
-// Saves given byte slice as text file.
+// Saves given byte slice as text file, atomically.
 // Returns error is any.
-func SaveFileText(filePath string, rawBytes []byte) error {
-	err := os.WriteFile(filePath, rawBytes, 0644)
+func SaveFileText(filePath string, rawBytes []byte, opts ...SaveOption) error {
+	return WriteFileAtomic(filePath, rawBytes, 0644, opts...)
+}
+
+// Writes data to filePath so that readers see either the old or the new content, never a
+// partial file. Data goes to a temp file in the same directory, which is fsynced and then
+// renamed over filePath, followed by an fsync of the directory. New files get perm as
+// masked by the umask, like os.WriteFile. If filePath is a symbolic link, the file it
+// points to is replaced and the link is kept, and backups are made next to that file.
+// Returns error if any.
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode, opts ...SaveOption) (err error) {
+	o := saveOptions{perm: perm}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	filePath, err = resolveSymlinks(filePath)
 	if err != nil {
-		return err
+		return wrapErr(ErrFileWrite, err)
+	}
+	var oldInfo os.FileInfo
+	if info, statErr := os.Stat(filePath); statErr == nil {
+		oldInfo = info
+		if o.preserveMode {
+			o.perm = info.Mode().Perm()
+			o.explicitPerm = true
+		}
+	}
+
+	dir := filepath.Dir(filePath)
+	tmp, err := createTemp(dir, "."+filepath.Base(filePath)+".tmp", o.perm)
+	if err != nil {
+		return wrapErr(ErrFileWrite, err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return wrapErr(ErrFileWrite, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return wrapErr(ErrFileWrite, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return wrapErr(ErrFileWrite, err)
+	}
+	if o.explicitPerm {
+		if err = os.Chmod(tmpPath, o.perm); err != nil {
+			return wrapErr(ErrFileWrite, err)
+		}
+	}
+	if o.preserveOwner && oldInfo != nil {
+		if err = chownLike(tmpPath, oldInfo); err != nil {
+			return wrapErr(ErrFileWrite, err)
+		}
+	}
+	if o.backups > 0 && oldInfo != nil {
+		if err = backupFile(filePath, o.backups); err != nil {
+			return wrapErr(ErrFileWrite, err)
+		}
+	}
+	if err = os.Rename(tmpPath, filePath); err != nil {
+		return wrapErr(ErrFileWrite, err)
+	}
+	if err = syncDir(dir); err != nil {
+		return wrapErr(ErrFileWrite, err)
 	}
 	return nil
 }
 
-// Removes given filepath
+// Creates a new file in dir named prefix plus a random suffix, with perm as masked by the
+// umask, and opens it for writing. Internal helper function.
+func createTemp(dir, prefix string, perm os.FileMode) (*os.File, error) {
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(rand.Uint64(), 36))
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if os.IsExist(err) && try < 100 {
+			continue
+		}
+		return f, err
+	}
+}
+
+// Returns filePath with any symbolic links it is followed to their final target, which
+// need not exist yet. Internal helper function.
+func resolveSymlinks(filePath string) (string, error) {
+	for hops := 0; hops < 255; hops++ {
+		info, err := os.Lstat(filePath)
+		if os.IsNotExist(err) || (err == nil && info.Mode()&os.ModeSymlink == 0) {
+			return filePath, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		target, err := os.Readlink(filePath)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(filePath), target)
+		}
+		filePath = target
+	}
+	return "", fmt.Errorf("%s: too many levels of symbolic links", filePath)
+}
+
+// Returns the path of the nth newest backup of filePath, counting from 0. Internal helper.
+func backupPath(filePath string, n int) string {
+	if n == 0 {
+		return filePath + ".bak"
+	}
+	return filePath + ".bak." + strconv.Itoa(n)
+}
+
+// Shifts existing backups of filePath one place older, dropping any beyond keep, then
+// replaces filePath + ".bak" with a copy of filePath. A hard link is tried first, since
+// it is cheap and the original is about to be renamed over anyway. Internal helper.
+func backupFile(filePath string, keep int) error {
+	for n := keep - 1; n > 0; n-- {
+		err := os.Rename(backupPath(filePath, n-1), backupPath(filePath, n))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	bakPath := backupPath(filePath, 0)
+	if err := os.Remove(bakPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(filePath, bakPath); err == nil {
+		return nil
+	}
+	src, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(bakPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// Removes given filepath. Panics on error.
+//
+// Deprecated: Use RemoveFileE, or MustRemoveFile to keep panicking.
 func RemoveFile(filePath string) {
+	MustRemoveFile(filePath)
+}
+
+// Same as RemoveFileE but panics on error
+func MustRemoveFile(filePath string) {
+	if err := RemoveFileE(filePath); err != nil {
+		panic(err.Error())
+	}
+}
+
+// Removes given filepath. Returns error if any. A filepath that does not exist is not
+// an error.
+func RemoveFileE(filePath string) error {
 	if FileExist(filePath) {
 		if err := os.Remove(filePath); err != nil {
-			panic(err.Error())
+			return wrapErr(ErrFileWrite, err)
 		}
 	}
+	return nil
 }
 
 // Returns true if filepath exists and has some content. False otherwise.
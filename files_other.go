@@ -0,0 +1,15 @@
+//go:build !unix
+
+package utl
+
+import "os"
+
+// Ownership is not preserved on this platform. Internal helper function.
+func chownLike(path string, info os.FileInfo) error {
+	return nil
+}
+
+// Directories can't be fsynced on this platform. Internal helper function.
+func syncDir(dir string) error {
+	return nil
+}
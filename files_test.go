@@ -0,0 +1,124 @@
+package utl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveFileE(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "x")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveFileE(path); err != nil || FileExist(path) {
+		t.Errorf("error = %v, exists = %v", err, FileExist(path))
+	}
+	if err := RemoveFileE(path); err != nil {
+		t.Errorf("missing file error = %v, want none", err)
+	}
+	full := filepath.Join(dir, "full")
+	if err := os.MkdirAll(filepath.Join(full, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveFileE(full); !errors.Is(err, ErrFileWrite) {
+		t.Errorf("non-empty directory error = %v, want ErrFileWrite", err)
+	}
+	if !panics(func() { MustRemoveFile(full) }) {
+		t.Errorf("MustRemoveFile did not panic")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "x.txt")
+	if err := WriteFileAtomic(path, []byte("one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(path, []byte("two"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "two" {
+		t.Errorf("content = %q, want two", b)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "x"), nil, 0644); !errors.Is(err, ErrFileWrite) {
+		t.Errorf("missing directory error = %v, want ErrFileWrite", err)
+	}
+}
+
+func TestWriteFileAtomicPerm(t *testing.T) {
+	dir := t.TempDir()
+	perm := func(path string) os.FileMode {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Mode().Perm()
+	}
+
+	// What the umask makes of 0666, as os.WriteFile would create it
+	probe := filepath.Join(dir, "probe")
+	if err := os.WriteFile(probe, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "x")
+	if err := WriteFileAtomic(path, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := perm(path), perm(probe); got != want {
+		t.Errorf("default mode = %v, want %v as masked by the umask", got, want)
+	}
+	if err := WriteFileAtomic(path, nil, 0644, WithPerm(0640)); err != nil || perm(path) != 0640 {
+		t.Errorf("WithPerm(0640) mode = %v, %v", perm(path), err)
+	}
+	if err := WriteFileAtomic(path, nil, 0600, WithPreserveMode()); err != nil || perm(path) != 0640 {
+		t.Errorf("WithPreserveMode() mode = %v, %v, want 0640 kept", perm(path), err)
+	}
+}
+
+func TestWriteFileAtomicBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x")
+	for _, content := range []string{"1", "2", "3", "4"} {
+		if err := WriteFileAtomic(path, []byte(content), 0600, WithBackups(2)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, want := range map[string]string{path: "4", path + ".bak": "3", path + ".bak.1": "2"} {
+		if b, err := os.ReadFile(name); err != nil || string(b) != want {
+			t.Errorf("%s = %q, %v, want %q", filepath.Base(name), b, err, want)
+		}
+	}
+	if FileExist(path + ".bak.2") {
+		t.Errorf("backup beyond the 2 kept exists")
+	}
+}
+
+func TestWriteFileAtomicSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+	if err := os.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target", link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	if err := WriteFileAtomic(link, []byte("new"), 0600, WithBackup()); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link was replaced: %v, %v", info, err)
+	}
+	if b, _ := os.ReadFile(target); string(b) != "new" {
+		t.Errorf("target = %q, want new", b)
+	}
+	if b, _ := os.ReadFile(target + ".bak"); string(b) != "old" {
+		t.Errorf("backup next to target = %q, want old", b)
+	}
+}
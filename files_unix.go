@@ -0,0 +1,27 @@
+//go:build unix
+
+package utl
+
+import (
+	"os"
+	"syscall"
+)
+
+// Sets path's owner and group to those recorded in info. Internal helper function.
+func chownLike(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return os.Chown(path, int(stat.Uid), int(stat.Gid))
+}
+
+// Fsyncs directory dir so a preceding rename within it is durable. Internal helper.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
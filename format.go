@@ -0,0 +1,257 @@
+package utl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Encoding and compression names used in FileFormat
+const (
+	EncodingJson    = "json"
+	EncodingYaml    = "yaml"
+	EncodingToml    = "toml"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// FileFormat describes how a file's content is encoded, and compressed if at all. An
+// empty Compression means the content is stored as plain text.
+type FileFormat struct {
+	Encoding    string
+	Compression string
+}
+
+// Returns format as "json", "yaml+gzip", "toml+zstd", and so on
+func (f FileFormat) String() string {
+	if f.Compression == "" {
+		return f.Encoding
+	}
+	return f.Encoding + "+" + f.Compression
+}
+
+// MaxDecompressedSize caps how many bytes LoadFileAny and BytesToObjectAny will inflate
+// gzip or zstd input to, so a small "decompression bomb" of unknown origin can't exhaust
+// memory. Going over it is an error wrapping ErrTooLarge. Raise it to load larger files.
+// The format specific loaders, like LoadFileJsonGzip, have no cap.
+var MaxDecompressedSize int64 = 256 << 20
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	// A key = value line, which only makes sense in TOML. A [table] header alone isn't
+	// enough, as it reads just as well as a YAML flow sequence.
+	tomlLineRegex = regexp.MustCompile(`(?m)^[ \t]*[\w\-."']+[ \t]*=`)
+)
+
+// Reads, load, and decode given filePath, working out by its content whether it is gzip
+// or zstd compressed, and whether it is JSON, TOML or YAML. The file's name is ignored.
+// Returns the decoded object, the detected format, and error if any.
+func LoadFileAny(filePath string) (obj interface{}, format FileFormat, err error) {
+	fileContent, err := LoadFileText(filePath)
+	if err != nil {
+		return nil, format, err
+	}
+	obj, format, err = BytesToObjectAny(fileContent)
+	return obj, format, withParseSource(err, filePath, nil)
+}
+
+// Decode given byte slice the same way LoadFileAny does.
+// Returns the decoded object, the detected format, and error if any.
+func BytesToObjectAny(data []byte) (obj interface{}, format FileFormat, err error) {
+	data, format.Compression, err = decompressAny(data)
+	if err != nil {
+		return nil, format, err
+	}
+	format.Encoding = DetectEncoding(data)
+	switch format.Encoding {
+	case EncodingJson:
+		obj, err = JsonBytesToJsonObj(data)
+	case EncodingToml:
+		var m map[string]interface{}
+		if err = toml.Unmarshal(data, &m); err != nil {
+			return nil, format, tomlParseError(err, data)
+		}
+		obj = m
+	default:
+		obj, err = BytesToYamlObject(data)
+		if errors.Is(err, io.EOF) {
+			err = nil // Empty document
+		}
+	}
+	return obj, format, err
+}
+
+// Returns the encoding of given uncompressed byte slice: EncodingJson if it is valid JSON,
+// EncodingToml if it has a key = value line and parses as TOML, and EncodingYaml otherwise.
+func DetectEncoding(data []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return EncodingJson
+	}
+	if tomlLineRegex.Match(trimmed) {
+		var m map[string]interface{}
+		if toml.Unmarshal(trimmed, &m) == nil {
+			return EncodingToml
+		}
+	}
+	return EncodingYaml
+}
+
+// Undoes gzip or zstd compression, identified by magic bytes. Returns the plain bytes,
+// the compression found, and error if any. Internal helper function.
+func decompressAny(data []byte) (plain []byte, compression string, err error) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, CompressionGzip, wrapErr(ErrFileRead, err)
+		}
+		defer r.Close()
+		plain, err = readDecompressed(r)
+		return plain, CompressionGzip, err
+	case bytes.HasPrefix(data, zstdMagic):
+		r, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, CompressionZstd, wrapErr(ErrFileRead, err)
+		}
+		defer r.Close()
+		plain, err = readDecompressed(r)
+		return plain, CompressionZstd, err
+	}
+	return data, "", nil
+}
+
+// Reads all of decompressing reader r. Returns error wrapping ErrTooLarge if it holds
+// more than MaxDecompressedSize bytes. Internal helper function.
+func readDecompressed(r io.Reader) ([]byte, error) {
+	plain, err := io.ReadAll(newDecompressLimiter(r))
+	if err != nil && !errors.Is(err, ErrTooLarge) {
+		err = wrapErr(ErrFileRead, err)
+	}
+	return plain, err
+}
+
+// decompressLimiter passes reads through to a decompressing reader until it has yielded
+// MaxDecompressedSize bytes, and then fails with ErrTooLarge if there's more
+type decompressLimiter struct {
+	r    io.Reader
+	left int64
+}
+
+// Returns a decompressLimiter reading from r. Internal helper function.
+func newDecompressLimiter(r io.Reader) *decompressLimiter {
+	return &decompressLimiter{r: r, left: MaxDecompressedSize}
+}
+
+func (l *decompressLimiter) Read(p []byte) (int, error) {
+	if l.left <= 0 {
+		var probe [1]byte
+		if n, err := l.r.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: decompresses to over %d bytes", ErrTooLarge, MaxDecompressedSize)
+	}
+	if int64(len(p)) > l.left {
+		p = p[:l.left]
+	}
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	return n, err
+}
+
+// Returns the format implied by filePath's extension, i.e. "x.json", "x.yml.gz" or
+// "x.toml.zst". Returns error wrapping ErrUnknownFormat if the extension isn't recognized.
+func FormatFromPath(filePath string) (format FileFormat, err error) {
+	name := strings.ToLower(filepath.Base(filePath))
+	ext := filepath.Ext(name)
+	switch ext {
+	case ".gz", ".gzip":
+		format.Compression = CompressionGzip
+	case ".zst", ".zstd":
+		format.Compression = CompressionZstd
+	}
+	if format.Compression != "" {
+		name = strings.TrimSuffix(name, ext)
+		ext = filepath.Ext(name)
+	}
+	switch ext {
+	case ".json":
+		format.Encoding = EncodingJson
+	case ".yaml", ".yml":
+		format.Encoding = EncodingYaml
+	case ".toml":
+		format.Encoding = EncodingToml
+	default:
+		return format, fmt.Errorf("%w: %s", ErrUnknownFormat, filePath)
+	}
+	return format, nil
+}
+
+// Save given object to filePath, atomically, picking the encoder and compression from the
+// file extension as described in FormatFromPath. Returns error if any.
+func SaveFileAny(obj interface{}, filePath string, opts ...SaveOption) error {
+	format, err := FormatFromPath(filePath)
+	if err != nil {
+		return err
+	}
+	data, err := ObjectToBytesAny(obj, format)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filePath, data, 0600, opts...)
+}
+
+// Encode, and compress if required, given object in given format.
+// Returns the resulting byte slice and error if any.
+func ObjectToBytesAny(obj interface{}, format FileFormat) (data []byte, err error) {
+	defer recoverMarshalPanic(&err)
+	switch format.Encoding {
+	case EncodingJson:
+		data, err = JsonToBytes(obj)
+	case EncodingYaml:
+		data, err = yaml.Marshal(obj)
+		err = wrapErr(ErrMarshal, err)
+	case EncodingToml:
+		data, err = toml.Marshal(obj)
+		err = wrapErr(ErrMarshal, err)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format.Encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch format.Compression {
+	case "":
+		return data, nil
+	case CompressionGzip:
+		w := gzip.NewWriter(&buf)
+		if _, err = w.Write(data); err == nil {
+			err = w.Close()
+		}
+	case CompressionZstd:
+		w, _ := zstd.NewWriter(&buf) // Only fails on invalid options
+		if _, err = w.Write(data); err == nil {
+			err = w.Close()
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format.Compression)
+	}
+	if err != nil {
+		return nil, wrapErr(ErrFileWrite, err)
+	}
+	return buf.Bytes(), nil
+}
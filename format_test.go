@@ -0,0 +1,104 @@
+package utl
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name, data, want string
+	}{
+		{"json object", `{"a": 1}`, EncodingJson},
+		{"json array", "\xef\xbb\xbf [1, 2]\n", EncodingJson},
+		{"yaml mapping", "a: 1\nb: [x]\n", EncodingYaml},
+		{"yaml flow sequence", "[foo]\n", EncodingYaml},
+		{"yaml flow sequence of names", "[foo, bar.baz]", EncodingYaml},
+		{"invalid json is yaml", "{a: 1}", EncodingYaml},
+		{"yaml with equals in a value", "a: x = 1\n", EncodingYaml},
+		{"toml keys", "title = \"x\"\n", EncodingToml},
+		{"toml table", "[server]\nhost = \"h\"\nport = 80\n", EncodingToml},
+		{"toml array of tables", "# c\n[[item]]\nname = \"a\"\n\n[[item]]\nname = \"b\"\n", EncodingToml},
+		{"toml-looking but invalid", "[server]\nhost = \n", EncodingYaml},
+		{"empty", "", EncodingYaml},
+	}
+	for _, tt := range tests {
+		if got := DetectEncoding([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: DetectEncoding(%q) = %s, want %s", tt.name, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want FileFormat
+	}{
+		{"a.json", FileFormat{Encoding: EncodingJson}},
+		{"dir.x/A.YML", FileFormat{Encoding: EncodingYaml}},
+		{"a.toml.zst", FileFormat{Encoding: EncodingToml, Compression: CompressionZstd}},
+		{"a.yaml.gz", FileFormat{Encoding: EncodingYaml, Compression: CompressionGzip}},
+	}
+	for _, tt := range tests {
+		if got, err := FormatFromPath(tt.path); err != nil || got != tt.want {
+			t.Errorf("FormatFromPath(%q) = %v, %v, want %v", tt.path, got, err, tt.want)
+		}
+	}
+	for _, path := range []string{"a.txt", "a.gz", "json"} {
+		if _, err := FormatFromPath(path); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("FormatFromPath(%q) error = %v, want ErrUnknownFormat", path, err)
+		}
+	}
+}
+
+func TestSaveLoadFileAny(t *testing.T) {
+	dir := t.TempDir()
+	obj := map[string]interface{}{"name": "x", "list": []interface{}{"a", "b"}}
+	for _, name := range []string{"a.json", "a.yaml", "a.toml", "a.json.gz", "a.yml.zst", "a.toml.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := SaveFileAny(obj, path); err != nil {
+				t.Fatalf("save error = %v", err)
+			}
+			got, format, err := LoadFileAny(path)
+			if err != nil || !reflect.DeepEqual(got, obj) {
+				t.Errorf("loaded %v, %v; want %v", got, err, obj)
+			}
+			if want, _ := FormatFromPath(path); format != want {
+				t.Errorf("detected %v, want %v", format, want)
+			}
+		})
+	}
+}
+
+func TestMaxDecompressedSize(t *testing.T) {
+	old := MaxDecompressedSize
+	t.Cleanup(func() { MaxDecompressedSize = old })
+	MaxDecompressedSize = 64
+
+	big := map[string]interface{}{"a": string(bytes.Repeat([]byte("x"), 1000))}
+	for _, format := range []FileFormat{{Encoding: EncodingJson, Compression: CompressionGzip}, {Encoding: EncodingJson, Compression: CompressionZstd}} {
+		data, err := ObjectToBytesAny(big, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := BytesToObjectAny(data); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%v error = %v, want ErrTooLarge", format, err)
+		}
+	}
+
+	// The format specific loaders aren't capped
+	path := filepath.Join(t.TempDir(), "big.json.gz")
+	if err := SaveFileJsonGzipE(big, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFileJsonGzip(path); err != nil {
+		t.Errorf("LoadFileJsonGzip error = %v", err)
+	}
+	if _, err := LoadFileJsonGzipAs[map[string]string](path); err != nil {
+		t.Errorf("LoadFileJsonGzipAs error = %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package utl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Returns the Levenshtein distance between a and b: the least number of single rune
+// insertions, deletions and substitutions that turn one into the other.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Returns the Damerau-Levenshtein distance between a and b, which also counts swapping
+// two adjacent runes as a single edit, the most common typo. This is the optimal string
+// alignment variant, where no substring is edited more than once.
+func DamerauLevenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// Returns the Jaro-Winkler similarity of a and b, from 0 for nothing in common to 1 for
+// identical. It favors strings sharing a prefix, which suits short names.
+func JaroWinkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	window := max(len(ra), len(rb))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i, r := range ra {
+		for j := max(0, i-window); j < min(len(rb), i+window+1); j++ {
+			if !matchedB[j] && rb[j] == r {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	transpositions, j := 0, 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Scoring for FuzzyMatch, the same as fzf's
+const (
+	fuzzyScoreMatch        = 16
+	fuzzyGapStart          = -3
+	fuzzyGapExtension      = -1
+	fuzzyBonusBoundary     = fuzzyScoreMatch / 2
+	fuzzyBonusWhite        = fuzzyBonusBoundary + 2
+	fuzzyBonusDelimiter    = fuzzyBonusBoundary + 1
+	fuzzyBonusNonWord      = fuzzyScoreMatch / 2
+	fuzzyBonusCamel123     = fuzzyBonusBoundary + fuzzyGapExtension
+	fuzzyBonusConsecutive  = -(fuzzyGapStart + fuzzyGapExtension)
+	fuzzyBonusFirstCharMul = 2
+)
+
+// Returns the bonus for matching rune r, which follows rune prev. Matches at the start of
+// words, after a delimiter or at a camelCase hump score higher. Internal helper function.
+func fuzzyBonus(prev, r rune) int {
+	wordChar := func(c rune) bool { return unicode.IsLetter(c) || unicode.IsDigit(c) }
+	switch {
+	case !wordChar(r):
+		return fuzzyBonusNonWord
+	case unicode.IsSpace(prev):
+		return fuzzyBonusWhite
+	case strings.ContainsRune("/,:;|-_.", prev):
+		return fuzzyBonusDelimiter
+	case !wordChar(prev):
+		return fuzzyBonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(r), !unicode.IsDigit(prev) && unicode.IsDigit(r):
+		return fuzzyBonusCamel123
+	}
+	return 0
+}
+
+// Scores how well query matches candidate as a subsequence, the way fzf does: every
+// query rune must appear in candidate, in order, and the score rewards consecutive runs
+// and matches at word boundaries while penalizing gaps. Matching ignores case unless
+// query has upper case letters. Returns the score, the rune positions in candidate that
+// matched, and whether it matched at all. An empty query matches with score 0.
+func FuzzyMatch(query, candidate string) (score int, positions []int, ok bool) {
+	q, text := []rune(query), []rune(candidate)
+	if len(q) == 0 {
+		return 0, nil, true
+	}
+	lower := text // Rune by rune, so positions line up with text
+	if query == strings.ToLower(query) {
+		lower = make([]rune, len(text))
+		for i, r := range text {
+			lower[i] = unicode.ToLower(r)
+		}
+	}
+
+	// Quick check that it's a subsequence at all
+	k := 0
+	for _, r := range lower {
+		if k < len(q) && r == q[k] {
+			k++
+		}
+	}
+	if k < len(q) {
+		return 0, nil, false
+	}
+
+	n := len(text)
+	bonus := make([]int, n)
+	for j, r := range text {
+		prev := ' '
+		if j > 0 {
+			prev = text[j-1]
+		}
+		bonus[j] = fuzzyBonus(prev, r)
+	}
+
+	// scores[i][j] is the best score with query rune i matched at candidate rune j, from
+	// the previous query rune matched at from[i][j], and chunk[i][j] the bonus of the
+	// consecutive run it ends
+	const none = -1 << 30
+	scores := make([][]int, len(q))
+	from := make([][]int, len(q))
+	chunk := make([][]int, len(q))
+	for i := range q {
+		scores[i] = make([]int, n)
+		from[i] = make([]int, n)
+		chunk[i] = make([]int, n)
+		gapBest, gapFrom := none, -1 // Best way to arrive at j after a gap
+		for j := 0; j < n; j++ {
+			if i > 0 && j >= 2 && scores[i-1][j-2] > none {
+				if s := scores[i-1][j-2] + fuzzyGapStart; s >= gapBest {
+					gapBest, gapFrom = s, j-2
+				}
+			}
+			scores[i][j] = none
+			if lower[j] != q[i] {
+				if gapBest > none {
+					gapBest += fuzzyGapExtension
+				}
+				continue
+			}
+			if i == 0 {
+				scores[i][j] = fuzzyScoreMatch + bonus[j]*fuzzyBonusFirstCharMul
+				from[i][j], chunk[i][j] = -1, bonus[j]
+				if gapBest > none {
+					gapBest += fuzzyGapExtension
+				}
+				continue
+			}
+			if gapBest > none {
+				scores[i][j] = gapBest + fuzzyScoreMatch + bonus[j]
+				from[i][j], chunk[i][j] = gapFrom, bonus[j]
+			}
+			if j > 0 && scores[i-1][j-1] > none {
+				b := max(bonus[j], chunk[i-1][j-1], fuzzyBonusConsecutive)
+				if s := scores[i-1][j-1] + fuzzyScoreMatch + b; s >= scores[i][j] {
+					scores[i][j] = s
+					from[i][j] = j - 1
+					chunk[i][j] = max(chunk[i-1][j-1], bonus[j])
+				}
+			}
+			if gapBest > none {
+				gapBest += fuzzyGapExtension
+			}
+		}
+	}
+
+	last := len(q) - 1
+	best := -1
+	for j := 0; j < n; j++ {
+		if scores[last][j] > none && (best < 0 || scores[last][j] > scores[last][best]) {
+			best = j
+		}
+	}
+	positions = make([]int, len(q))
+	for i, j := last, best; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+	return scores[last][best], positions, true
+}
+
+// FuzzyResult is a candidate matched by FuzzyFind
+type FuzzyResult struct {
+	Candidate string
+	Index     int   // Of Candidate in the candidates given to FuzzyFind
+	Score     int   // Higher is better
+	Positions []int // Matched rune positions in Candidate, for HighlightMatches
+}
+
+// Returns the candidates matching query as per FuzzyMatch, best first. Ties go to the
+// shorter candidate, then to the one given first. An empty query returns all candidates
+// in their original order.
+func FuzzyFind(query string, candidates []string) []FuzzyResult {
+	var results []FuzzyResult
+	for i, c := range candidates {
+		if score, positions, ok := FuzzyMatch(query, c); ok {
+			results = append(results, FuzzyResult{Candidate: c, Index: i, Score: score, Positions: positions})
+		}
+	}
+	if query == "" {
+		return results
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		ra, rb := results[a], results[b]
+		if ra.Score != rb.Score {
+			return ra.Score > rb.Score
+		}
+		return len([]rune(ra.Candidate)) < len([]rune(rb.Candidate))
+	})
+	return results
+}
+
+// Returns s with the runes at given positions, like FuzzyResult.Positions, rendered in
+// style, runs of adjacent positions as one span. A nil style uses the active theme's
+// Highlight, as far as stdout takes colors.
+func HighlightMatches(s string, positions []int, style StyleFunc) string {
+	if style == nil {
+		style = forStdout(activeTheme.Highlight)
+	}
+	marked := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		marked[p] = true
+	}
+	var sb, span strings.Builder
+	for i, r := range []rune(s) {
+		if marked[i] {
+			span.WriteRune(r)
+			continue
+		}
+		if span.Len() > 0 {
+			sb.WriteString(style(span.String()))
+			span.Reset()
+		}
+		sb.WriteRune(r)
+	}
+	if span.Len() > 0 {
+		sb.WriteString(style(span.String()))
+	}
+	return sb.String()
+}
@@ -0,0 +1,123 @@
+package utl
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestEditDistances(t *testing.T) {
+	tests := []struct {
+		a, b      string
+		lev, dlev int
+	}{
+		{"", "", 0, 0},
+		{"", "abc", 3, 3},
+		{"kitten", "sitting", 3, 3},
+		{"flaw", "lawn", 2, 2},
+		{"ab", "ba", 2, 1},
+		{"ca", "abc", 3, 3}, // Optimal string alignment doesn't edit the swap again
+		{"héllo", "hello", 1, 1},
+		{"日本", "本日", 2, 1},
+	}
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.lev {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.lev)
+		}
+		if got := DamerauLevenshtein(tt.a, tt.b); got != tt.dlev {
+			t.Errorf("DamerauLevenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.dlev)
+		}
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"abc", "", 0},
+		{"abc", "abc", 1},
+		{"abc", "xyz", 0},
+		{"MARTHA", "MARHTA", 0.961},
+		{"DIXON", "DICKSONX", 0.813},
+		{"DWAYNE", "DUANE", 0.840},
+	}
+	for _, tt := range tests {
+		if got := JaroWinkler(tt.a, tt.b); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("JaroWinkler(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, candidate string
+		ok               bool
+		positions        []int
+	}{
+		{"", "anything", true, nil},
+		{"abc", "a-b-c", true, []int{0, 2, 4}},
+		{"fb", "foo_bar", true, []int{0, 4}},
+		{"fb", "FooBar", true, []int{0, 3}},
+		{"FB", "foobar", false, nil}, // Upper case in query is matched exactly
+		{"cba", "abc", false, nil},
+		{"ab", "xab", true, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		_, positions, ok := FuzzyMatch(tt.query, tt.candidate)
+		if ok != tt.ok || !reflect.DeepEqual(positions, tt.positions) {
+			t.Errorf("FuzzyMatch(%q, %q) = %v, %v, want %v, %v", tt.query, tt.candidate, positions, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyMatchPrefersBoundaries(t *testing.T) {
+	boundary, _, _ := FuzzyMatch("fb", "foo_bar")
+	middle, _, _ := FuzzyMatch("fb", "xfxxxb")
+	if boundary <= middle {
+		t.Errorf("word boundary score %d not above mid-word score %d", boundary, middle)
+	}
+	run, _, _ := FuzzyMatch("abc", "xabcx")
+	gaps, _, _ := FuzzyMatch("abc", "xaxbxcx")
+	if run <= gaps {
+		t.Errorf("consecutive score %d not above gapped score %d", run, gaps)
+	}
+}
+
+func TestFuzzyFind(t *testing.T) {
+	candidates := []string{"readme.md", "main.go", "Makefile", "pkg/mod/main_test.go", "mango"}
+	var got []string
+	for _, r := range FuzzyFind("mgo", candidates) {
+		got = append(got, r.Candidate)
+		if candidates[r.Index] != r.Candidate {
+			t.Errorf("result %q has Index %d", r.Candidate, r.Index)
+		}
+	}
+	want := []string{"main.go", "pkg/mod/main_test.go", "mango"} // Word boundaries win
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FuzzyFind = %q, want %q", got, want)
+	}
+	if n := len(FuzzyFind("", candidates)); n != len(candidates) {
+		t.Errorf("empty query found %d, want all %d", n, len(candidates))
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	brackets := func(a ...interface{}) string { return "[" + a[0].(string) + "]" }
+	tests := []struct {
+		s         string
+		positions []int
+		want      string
+	}{
+		{"foo_bar", []int{0, 4}, "[f]oo_[b]ar"},
+		{"abcdef", []int{1, 2, 3}, "a[bcd]ef"},
+		{"日本語", []int{2}, "日本[語]"},
+		{"abc", nil, "abc"},
+	}
+	for _, tt := range tests {
+		if got := HighlightMatches(tt.s, tt.positions, brackets); got != tt.want {
+			t.Errorf("HighlightMatches(%q, %v) = %q, want %q", tt.s, tt.positions, got, tt.want)
+		}
+	}
+}
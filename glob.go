@@ -0,0 +1,233 @@
+package utl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Glob is a compiled glob pattern, matched against whole strings:
+//
+//	"*"       any run of characters except "/"
+//	"**"      any run of characters, "/" included; "a/**/b" also matches "a/b"
+//	"?"       any one character except "/"
+//	"[abc]"   one of the characters listed, ranges like [a-z0-9] and classes like
+//	          [[:digit:]] allowed; [!abc] or [^abc] for any other character but "/"
+//	"\x"     character x itself, i.e. \* for a literal asterisk
+//	"!"       at the very start, negates the pattern: "!prod-*" matches what "prod-*" doesn't
+type Glob struct {
+	pattern string
+	re      *regexp.Regexp
+	negate  bool
+}
+
+// GlobOption tweaks how a glob pattern matches
+type GlobOption func(*globOptions)
+
+type globOptions struct {
+	ignoreCase bool
+}
+
+// Match letters regardless of case
+func WithGlobIgnoreCase() GlobOption {
+	return func(o *globOptions) { o.ignoreCase = true }
+}
+
+// Compiles given glob pattern, see Glob for the syntax. Returns error wrapping
+// ErrInvalidPattern if the pattern is malformed, like an unclosed "[".
+func CompileGlob(pattern string, opts ...GlobOption) (*Glob, error) {
+	var o globOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	g := &Glob{pattern: pattern}
+	body := pattern
+	if strings.HasPrefix(body, "!") {
+		g.negate = true
+		body = body[1:]
+	}
+	expr, err := globToRegexp(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %s", ErrInvalidPattern, pattern, err.Error())
+	}
+	flags := "(?s)"
+	if o.ignoreCase {
+		flags = "(?si)"
+	}
+	g.re, err = regexp.Compile(flags + "^" + expr + "$")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", ErrInvalidPattern, pattern, err)
+	}
+	return g, nil
+}
+
+// Same as CompileGlob but panics on error
+func MustCompileGlob(pattern string, opts ...GlobOption) *Glob {
+	g, err := CompileGlob(pattern, opts...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return g
+}
+
+// Returns true if s matches the pattern, or doesn't if the pattern is negated
+func (g *Glob) Match(s string) bool {
+	return g.re.MatchString(s) != g.negate
+}
+
+// Returns true if the pattern starts with "!"
+func (g *Glob) Negated() bool {
+	return g.negate
+}
+
+// Returns the pattern the Glob was compiled from
+func (g *Glob) String() string {
+	return g.pattern
+}
+
+// Returns true if s matches glob pattern, see Glob for the syntax.
+// Returns error if the pattern is malformed.
+func MatchGlob(pattern, s string, opts ...GlobOption) (bool, error) {
+	g, err := CompileGlob(pattern, opts...)
+	if err != nil {
+		return false, err
+	}
+	return g.Match(s), nil
+}
+
+// Returns true if s matches any of given patterns and isn't excluded by a negated one, so
+// {"prod-*", "!prod-test"} takes every prod- name but prod-test. Negated patterns alone
+// take everything they don't exclude, while no patterns take nothing, as with ItemInList.
+// Returns error if a pattern is malformed.
+func MatchAnyGlob(s string, patterns []string, opts ...GlobOption) (bool, error) {
+	globs := make([]*Glob, len(patterns))
+	for i, p := range patterns {
+		g, err := CompileGlob(p, opts...)
+		if err != nil {
+			return false, err
+		}
+		globs[i] = g
+	}
+	return matchAnyGlob(s, globs), nil
+}
+
+// Worker for MatchAnyGlob. Internal helper function.
+func matchAnyGlob(s string, globs []*Glob) bool {
+	included, positives := false, false
+	for _, g := range globs {
+		if g.negate {
+			if !g.Match(s) {
+				return false
+			}
+			continue
+		}
+		positives = true
+		if g.Match(s) {
+			included = true
+		}
+	}
+	return included || (!positives && len(globs) > 0)
+}
+
+// Compiles pattern, or, if it's malformed, a Glob matching it literally, so patterns
+// typed by users never fail. Internal helper function.
+func compileGlobLenient(pattern string, opts ...GlobOption) *Glob {
+	if g, err := CompileGlob(pattern, opts...); err == nil {
+		return g
+	}
+	return MustCompileGlob(regexp.MustCompile(`[*?\[\]\\!]`).ReplaceAllString(pattern, `\$0`), opts...)
+}
+
+// Translates a glob pattern, without any leading "!", into a regular expression.
+// Internal helper function.
+func globToRegexp(pattern string) (string, error) {
+	var sb strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch r {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				atStart := i == 0 || runes[i-1] == '/'
+				i++
+				if atStart && i+1 < len(runes) && runes[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?") // "**/" also matches no directory at all
+				} else {
+					sb.WriteString(".*")
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			class, n, err := globClass(runes[i:])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(class)
+			i += n - 1
+		case '\\':
+			if i+1 == len(runes) {
+				return "", fmt.Errorf("trailing backslash")
+			}
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return sb.String(), nil
+}
+
+// Translates the character class at the start of runes into a regular expression class.
+// Returns it and the number of runes it took up. Internal helper function.
+func globClass(runes []rune) (string, int, error) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	i := 1
+	if i < len(runes) && (runes[i] == '!' || runes[i] == '^') {
+		sb.WriteString("^/") // Negated classes don't match the separator either
+		i++
+	}
+	for first := true; i < len(runes); first = false {
+		r := runes[i]
+		switch {
+		case r == ']' && !first:
+			sb.WriteString("]")
+			return sb.String(), i + 1, nil
+		case r == '[' && i+1 < len(runes) && runes[i+1] == ':':
+			name, _, found := strings.Cut(string(runes[i:]), ":]")
+			if !found {
+				return "", 0, fmt.Errorf("unclosed character class name")
+			}
+			sb.WriteString(name + ":]") // Go's regexp knows the same POSIX names
+			i += len([]rune(name)) + 2
+			continue
+		case r == '\\':
+			if i+1 == len(runes) {
+				return "", 0, fmt.Errorf("trailing backslash")
+			}
+			i++
+			sb.WriteString(classLiteral(runes[i]))
+			i++
+			continue
+		}
+		if r == '-' && !first && i+1 < len(runes) && runes[i+1] != ']' {
+			sb.WriteString("-") // A range
+		} else {
+			sb.WriteString(classLiteral(r))
+		}
+		i++
+	}
+	return "", 0, fmt.Errorf("unclosed character class")
+}
+
+// Returns r escaped for use inside a regular expression class. Internal helper function.
+func classLiteral(r rune) string {
+	if r == '-' {
+		return `\-`
+	}
+	return regexp.QuoteMeta(string(r))
+}
@@ -0,0 +1,116 @@
+package utl
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"prod-*", "prod-web", true},
+		{"prod-*", "dev-web", false},
+		{"*", "a/b", false},
+		{"**", "a/b", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/*/b", "a/x/y/b", false},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file10.txt", false},
+		{"?", "/", false},
+		{"*-[0-9][0-9]", "web-42", true},
+		{"*-[0-9][0-9]", "web-4x", false},
+		{"[!abc]", "d", true},
+		{"[^abc]", "a", false},
+		{"[!a]", "/", false},
+		{"[[:digit:]]x", "7x", true},
+		{"[]]", "]", true},
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{"a.b", "axb", false}, // Regexp characters are literal
+		{"(x)+", "(x)+", true},
+		{"!prod-*", "dev-web", true},
+		{"!prod-*", "prod-web", false},
+		{"日*", "日本", true},
+		{"a*", "a\nb", true},
+	}
+	for _, tt := range tests {
+		got, err := MatchGlob(tt.pattern, tt.s)
+		if err != nil {
+			t.Errorf("MatchGlob(%q, %q) error = %v", tt.pattern, tt.s, err)
+		} else if got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCompileGlobErrors(t *testing.T) {
+	for _, pattern := range []string{"[abc", `abc\`, "[[:nope:]]", "[z-a]"} {
+		if _, err := CompileGlob(pattern); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("CompileGlob(%q) error = %v, want ErrInvalidPattern", pattern, err)
+		}
+	}
+}
+
+func TestGlobIgnoreCase(t *testing.T) {
+	g := MustCompileGlob("Prod-[A-C]*", WithGlobIgnoreCase())
+	if !g.Match("PROD-bx") || g.Match("prod-dx") {
+		t.Errorf("%q ignoring case matched wrong", g)
+	}
+	if MustCompileGlob("Prod-*").Match("prod-x") {
+		t.Errorf("case sensitive glob matched other case")
+	}
+}
+
+func TestMatchAnyGlob(t *testing.T) {
+	tests := []struct {
+		s        string
+		patterns []string
+		want     bool
+	}{
+		{"prod-web", []string{"prod-*", "!prod-test"}, true},
+		{"prod-test", []string{"prod-*", "!prod-test"}, false},
+		{"dev-web", []string{"prod-*", "!prod-test"}, false},
+		{"dev-web", []string{"!prod-*"}, true},
+		{"prod-web", []string{"!prod-*"}, false},
+		{"x", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := MatchAnyGlob(tt.s, tt.patterns)
+		if err != nil || got != tt.want {
+			t.Errorf("MatchAnyGlob(%q, %q) = %v, %v, want %v", tt.s, tt.patterns, got, err, tt.want)
+		}
+		if got := ItemInListGlob(tt.s, tt.patterns); got != tt.want {
+			t.Errorf("ItemInListGlob(%q, %q) = %v, want %v", tt.s, tt.patterns, got, tt.want)
+		}
+	}
+	if ItemInListGlob("[x", []string{"[x"}) != true {
+		t.Errorf("malformed pattern not matched literally")
+	}
+}
+
+func TestStringInJsonGlob(t *testing.T) {
+	ordered := NewOrderedMap()
+	ordered.Set("env", "prod-web")
+	tests := []struct {
+		name    string
+		obj     interface{}
+		pattern string
+		want    bool
+	}{
+		{"nested", mustJsonObj(t, `{"a":[1,{"b":"prod-web"}]}`), "prod-*", true},
+		{"whole value only", mustJsonObj(t, `{"a":"my-prod-web"}`), "prod-*", false},
+		{"keys ignored", mustJsonObj(t, `{"prod-web":1}`), "prod-*", false},
+		{"ordered map", []interface{}{ordered}, "prod-*", true},
+		{"yaml.v3 map", map[interface{}]interface{}{"a": map[interface{}]interface{}{1: "prod-web"}}, "prod-*", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StringInJsonGlob(tt.obj, tt.pattern); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
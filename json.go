@@ -11,85 +11,90 @@ import (
 )
 
 // Reads, load, and decode given filePath as a JSON object text file.
-// Returns JSON object and err if any.
-func LoadFileJson(filePath string) (jsonObject interface{}, err error) {
+// Accepts WithOrderedMaps(). Returns JSON object and err if any.
+func LoadFileJson(filePath string, opts ...LoadOption) (jsonObject interface{}, err error) {
 	f, err := os.Open(filePath)
 	if err != nil {
-		return nil, err
+		return nil, readErr(err)
 	}
 	defer f.Close()
-	byteValue, err := io.ReadAll(f)
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal([]byte(byteValue), &jsonObject)
-	if err != nil {
-		return nil, err
-	}
-	return jsonObject, nil
+	jsonObject, err = DecodeJson(f, opts...)
+	return jsonObject, fileParseError(err, filePath)
 }
 
 // Reads, load, and decode given filePath as a gzipped JSON object text file.
-// Returns JSON object and err if any.
+// Accepts WithOrderedMaps(). Returns JSON object and err if any.
 // The compression helps speed things up for some very large objects.
-func LoadFileJsonGzip(filePath string) (jsonObject interface{}, err error) {
+func LoadFileJsonGzip(filePath string, opts ...LoadOption) (jsonObject interface{}, err error) {
 	f, err := os.Open(filePath)
 	if err != nil {
-		return nil, err
+		return nil, readErr(err)
 	}
 	defer f.Close()
 
 	gzipReader, err := gzip.NewReader(f)
 	if err != nil {
-		return nil, err
+		return nil, readErr(err)
 	}
 	defer gzipReader.Close()
 
-	byteValue, err := io.ReadAll(gzipReader)
-	if err != nil {
-		return nil, err
-	}
+	jsonObject, err = DecodeJson(gzipReader, opts...)
+	return jsonObject, fileParseError(err, filePath)
+}
 
-	err = json.Unmarshal([]byte(byteValue), &jsonObject)
-	if err != nil {
-		return nil, err
-	}
+// Save given JSON object as text file, atomically. Panics on error.
+//
+// Deprecated: Use SaveFileJsonE, or MustSaveFileJson to keep panicking.
+func SaveFileJson(jsonObject interface{}, filePath string) {
+	MustSaveFileJson(jsonObject, filePath)
+}
 
-	return jsonObject, nil
+// Same as SaveFileJsonE but panics on error
+func MustSaveFileJson(jsonObject interface{}, filePath string, opts ...SaveOption) {
+	if err := SaveFileJsonE(jsonObject, filePath, opts...); err != nil {
+		panic(err.Error())
+	}
 }
 
-// Save given JSON object as text file
-func SaveFileJson(jsonObject interface{}, filePath string) {
+// Save given JSON object as text file, atomically. Returns error if any.
+func SaveFileJsonE(jsonObject interface{}, filePath string, opts ...SaveOption) error {
 	jsonData, err := json.Marshal(jsonObject)
 	if err != nil {
-		panic(err.Error())
-	}
-	err = os.WriteFile(filePath, jsonData, 0600)
-	if err != nil {
-		panic(err.Error())
+		return wrapErr(ErrMarshal, err)
 	}
+	return WriteFileAtomic(filePath, jsonData, 0600, opts...)
 }
 
-// Save given JSON object as gzipped text file
+// Save given JSON object as gzipped text file, atomically. Panics on error.
+//
+// Deprecated: Use SaveFileJsonGzipE, or MustSaveFileJsonGzip to keep panicking.
 func SaveFileJsonGzip(jsonObject interface{}, filePath string) {
-	jsonData, err := json.Marshal(jsonObject)
-	if err != nil {
+	MustSaveFileJsonGzip(jsonObject, filePath)
+}
+
+// Same as SaveFileJsonGzipE but panics on error
+func MustSaveFileJsonGzip(jsonObject interface{}, filePath string, opts ...SaveOption) {
+	if err := SaveFileJsonGzipE(jsonObject, filePath, opts...); err != nil {
 		panic(err.Error())
 	}
+}
 
-	file, err := os.Create(filePath)
+// Save given JSON object as gzipped text file, atomically. Returns error if any.
+func SaveFileJsonGzipE(jsonObject interface{}, filePath string, opts ...SaveOption) error {
+	jsonData, err := json.Marshal(jsonObject)
 	if err != nil {
-		panic(err.Error())
+		return wrapErr(ErrMarshal, err)
 	}
-	defer file.Close()
-
-	gzipWriter := gzip.NewWriter(file)
-	defer gzipWriter.Close()
 
-	_, err = gzipWriter.Write(jsonData)
-	if err != nil {
-		panic(err.Error())
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	if _, err = gzipWriter.Write(jsonData); err != nil {
+		return wrapErr(ErrFileWrite, err)
+	}
+	if err = gzipWriter.Close(); err != nil {
+		return wrapErr(ErrFileWrite, err)
 	}
+	return WriteFileAtomic(filePath, buf.Bytes(), 0600, opts...)
 }
 
 // Prints JSON object, flushing the output buffer
@@ -109,7 +114,7 @@ func JsonBytesReindent(jsonBytes []byte, indent int) (jsonBytes2 []byte, err err
 	indentStr := strings.Repeat(" ", indent)
 	err = json.Indent(&prettyJson, jsonBytes, "", indentStr)
 	if err != nil {
-		return nil, err
+		return nil, withParseSource(jsonParseError(err, -1), "", jsonBytes)
 	}
 	jsonBytes2 = prettyJson.Bytes()
 	return jsonBytes2, nil
@@ -120,7 +125,7 @@ func JsonToBytesIndent(jsonObject interface{}, indent int) (jsonBytes []byte, er
 	indentStr := strings.Repeat(" ", indent)
 	jsonBytes, err = json.MarshalIndent(jsonObject, "", indentStr)
 	if err != nil {
-		return nil, err
+		return nil, wrapErr(ErrMarshal, err)
 	}
 	return jsonBytes, nil
 }
@@ -132,10 +137,17 @@ func JsonToBytes(jsonObject interface{}) (jsonBytes []byte, err error) {
 	return jsonBytes, err
 }
 
-// Convert JSON byte slice to JSON interface object, with default 2-space indentation
-func JsonBytesToJsonObj(jsonBytes []byte) (jsonObject interface{}, err error) {
+// Convert JSON byte slice to JSON interface object. Accepts WithOrderedMaps().
+func JsonBytesToJsonObj(jsonBytes []byte, opts ...LoadOption) (jsonObject interface{}, err error) {
+	if newLoadOptions(opts).ordered {
+		jsonObject, err = decodeOrderedJsonSingle(bytes.NewReader(jsonBytes))
+		return jsonObject, withParseSource(err, "", jsonBytes)
+	}
 	err = json.Unmarshal(jsonBytes, &jsonObject)
-	return jsonObject, err
+	if err != nil {
+		return nil, withParseSource(jsonParseError(err, -1), "", jsonBytes)
+	}
+	return jsonObject, nil
 }
 
 // NOTE: To be replaced by JsonToBytes()
@@ -144,19 +156,22 @@ func Prettify(jsonObject interface{}) (pretty string, err error) {
 	return string(j), err
 }
 
-// Print JSON object in color
-func PrintJsonColor(jsonObject interface{}) {
+// Print JSON object in color. See PrintYamlBytesColor for opts.
+// Returns marshal or write error, if any.
+func PrintJsonColor(jsonObject interface{}, opts ...PrintOption) error {
 	jsonBytes, err := JsonToBytes(jsonObject)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return err
 	}
-	PrintJsonBytesColor(jsonBytes)
+	return PrintJsonBytesColor(jsonBytes, opts...)
 }
 
-// Prints JSON byte slice in color. Just an alias of yaml.go:PrintYamlBytesColor().
-func PrintJsonBytesColor(jsonBytes []byte) {
-	PrintYamlBytesColor(jsonBytes)
+// Prints JSON byte slice in color, keeping its layout. Invalid JSON is printed with the
+// error position highlighted, see PrintJsonStreamColor. See PrintYamlBytesColor for opts.
+// Returns a *ParseError for invalid JSON, or write error, if any.
+func PrintJsonBytesColor(jsonBytes []byte, opts ...PrintOption) error {
+	width := lineNumberWidth(bytes.Count(jsonBytes, []byte("\n")) + 1)
+	return printJsonColor(bytes.NewReader(jsonBytes), newPrintOptions(opts), width)
 }
 
 // Combines two string-to-string maps, with keys from the second map overwriting those
@@ -174,6 +189,8 @@ func MergeMaps(m1, m2 map[string]string) (result map[string]string) {
 
 // Non-recursive merge of first-level attributes in JSON object y onto object x
 // If attribute exists in y, it is overwritten
+//
+// Deprecated: Modifies x in place and ignores nil values in y. Use MergeDeep instead.
 func MergeObjects(x, y map[string]interface{}) (obj map[string]interface{}) {
 	obj = x
 	for k, v := range x { // Update existing x values with updated y values
@@ -191,6 +208,8 @@ func MergeObjects(x, y map[string]interface{}) (obj map[string]interface{}) {
 }
 
 // Recursive function returns True if filter string value is anywhere within jsonObject
+// Use SearchJson to find out where, or to also match keys, numbers and regexes, and
+// StringInJsonGlob to match whole values against a pattern.
 func StringInJson(jsonObject interface{}, filter string) bool {
 	switch value := jsonObject.(type) {
 	case string:
@@ -210,3 +229,78 @@ func StringInJson(jsonObject interface{}, filter string) bool {
 	}
 	return false
 }
+
+// Returns true if any string value anywhere within jsonObject matches glob pattern, see
+// Glob, i.e. "prod-*" or "*-[0-9][0-9]". A malformed pattern is matched literally.
+// Objects may also be *OrderedMap, as from WithOrderedMaps(), or yaml.v3's
+// map[interface{}]interface{}.
+func StringInJsonGlob(jsonObject interface{}, pattern string, opts ...GlobOption) bool {
+	return stringInJsonGlob(orderedToPlain(jsonObject), compileGlobLenient(pattern, opts...))
+}
+
+// Recursive worker for StringInJsonGlob. Internal helper function.
+func stringInJsonGlob(obj interface{}, g *Glob) bool {
+	switch value := obj.(type) {
+	case string:
+		return g.Match(value)
+	case []interface{}:
+		for _, v := range value {
+			if stringInJsonGlob(v, g) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, v := range value {
+			if stringInJsonGlob(v, g) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Decodes a single JSON value from given reader into a value of type T. Option WithStrict()
+// makes fields absent from T an error wrapping ErrUnknownField, naming the offending field.
+// Returns decoded value and err if any.
+func DecodeJsonAs[T any](r io.Reader, opts ...LoadOption) (value T, err error) {
+	err = decodeJsonSingle(r, &value, newLoadOptions(opts).strict)
+	return value, err
+}
+
+// Convert JSON byte slice to a value of type T. See DecodeJsonAs for options.
+// Returns decoded value and err if any.
+func JsonBytesToJsonObjAs[T any](jsonBytes []byte, opts ...LoadOption) (value T, err error) {
+	value, err = DecodeJsonAs[T](bytes.NewReader(jsonBytes), opts...)
+	return value, withParseSource(err, "", jsonBytes)
+}
+
+// Reads, load, and decode given filePath as a JSON text file into a value of type T.
+// See DecodeJsonAs for options. Returns decoded value and err if any.
+func LoadFileJsonAs[T any](filePath string, opts ...LoadOption) (value T, err error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return value, readErr(err)
+	}
+	defer f.Close()
+	value, err = DecodeJsonAs[T](f, opts...)
+	return value, fileParseError(err, filePath)
+}
+
+// Reads, load, and decode given filePath as a gzipped JSON text file into a value of
+// type T. See DecodeJsonAs for options. Returns decoded value and err if any.
+func LoadFileJsonGzipAs[T any](filePath string, opts ...LoadOption) (value T, err error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return value, readErr(err)
+	}
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return value, readErr(err)
+	}
+	defer gzipReader.Close()
+
+	value, err = DecodeJsonAs[T](gzipReader, opts...)
+	return value, fileParseError(err, filePath)
+}
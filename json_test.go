@@ -0,0 +1,79 @@
+package utl
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveFileJsonE(t *testing.T) {
+	dir := t.TempDir()
+	obj := map[string]interface{}{"a": []interface{}{1.0, "x"}}
+	tests := []struct {
+		name string
+		save func(obj interface{}, path string, opts ...SaveOption) error
+		load func(path string, opts ...LoadOption) (interface{}, error)
+	}{
+		{"plain", SaveFileJsonE, LoadFileJson},
+		{"gzip", SaveFileJsonGzipE, LoadFileJsonGzip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			if err := tt.save(obj, path); err != nil {
+				t.Fatalf("save error = %v", err)
+			}
+			got, err := tt.load(path)
+			if err != nil || !reflect.DeepEqual(got, obj) {
+				t.Errorf("loaded %v, %v; want %v", got, err, obj)
+			}
+			if err := tt.save(make(chan int), path); !errors.Is(err, ErrMarshal) {
+				t.Errorf("unmarshalable value error = %v, want ErrMarshal", err)
+			}
+			if err := tt.save(obj, filepath.Join(dir, "missing", "x.json")); !errors.Is(err, ErrFileWrite) {
+				t.Errorf("missing directory error = %v, want ErrFileWrite", err)
+			}
+			if _, err := tt.load(filepath.Join(dir, "missing.json")); !errors.Is(err, ErrFileNotFound) {
+				t.Errorf("missing file error = %v, want ErrFileNotFound", err)
+			}
+		})
+	}
+}
+
+func TestMustSaveFileJsonPanics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "x.json")
+	for name, fn := range map[string]func(){
+		"MustSaveFileJson":     func() { MustSaveFileJson(1, path) },
+		"MustSaveFileJsonGzip": func() { MustSaveFileJsonGzip(1, path) },
+		"SaveFileJson":         func() { SaveFileJson(1, path) },
+	} {
+		if !panics(fn) {
+			t.Errorf("%s did not panic", name)
+		}
+	}
+}
+
+// Returns true if fn panics
+func panics(fn func()) (panicked bool) {
+	defer func() { panicked = recover() != nil }()
+	fn()
+	return false
+}
+
+func TestPrintColorMarshalError(t *testing.T) {
+	bad := map[string]interface{}{"c": make(chan int)}
+	for name, print := range map[string]func(interface{}, ...PrintOption) error{
+		"PrintJsonColor": PrintJsonColor,
+		"PrintYamlColor": PrintYamlColor,
+	} {
+		var buf bytes.Buffer
+		if err := print(bad, WithWriter(&buf)); !errors.Is(err, ErrMarshal) {
+			t.Errorf("%s error = %v, want ErrMarshal", name, err)
+		}
+		if buf.Len() > 0 {
+			t.Errorf("%s wrote %q", name, buf.String())
+		}
+	}
+}
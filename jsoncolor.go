@@ -0,0 +1,224 @@
+package utl
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Prints JSON read from r in color, one token at a time, so input of any size is printed
+// in constant memory. The original layout is kept, and a stream of several JSON values,
+// like NDJSON, works too. Invalid input is printed up to the error, with the offending
+// character highlighted, followed by the rest of the input uncolored. See
+// PrintYamlBytesColor for opts. Line numbers get four digits of room, as the line count
+// isn't known up front. Returns a *ParseError for invalid input, or a read or write
+// error, if any.
+func PrintJsonStreamColor(r io.Reader, opts ...PrintOption) error {
+	return printJsonColor(r, newPrintOptions(opts), streamLineNumberWidth)
+}
+
+// Line number column width for PrintJsonStreamColor
+const streamLineNumberWidth = 4
+
+// Colorizes JSON read from r, with a line number column of given width. Internal helper
+// function.
+func printJsonColor(r io.Reader, o printOptions, lineNumWidth int) error {
+	src := &jsonSourceTee{r: r}
+	decoder := json.NewDecoder(src)
+	decoder.UseNumber()
+	c := &jsonColorizer{out: newColorLineWriter(o, lineNumWidth), theme: o.theme}
+
+	var stack []jsonColorFrame
+	var parseErr error
+	for {
+		tk, err := decoder.Token()
+		if err == io.EOF && len(stack) > 0 {
+			err = io.ErrUnexpectedEOF // Token doesn't check that containers got closed
+		} else if err == io.EOF {
+			break
+		}
+		if err != nil {
+			parseErr = jsonParseError(err, decoder.InputOffset())
+			break
+		}
+		chunk := string(src.take(decoder.InputOffset()))
+		lit := strings.TrimLeft(chunk, " \t\r\n,:")
+		c.emitSeparators(chunk[:len(chunk)-len(lit)])
+
+		style := c.theme.Plain
+		switch value := tk.(type) {
+		case json.Delim:
+			switch value {
+			case '{', '[':
+				stack = append(stack, jsonColorFrame{object: value == '{', key: value == '{'})
+			default:
+				stack = stack[:len(stack)-1]
+				jsonColorValueDone(stack)
+			}
+		case string:
+			if n := len(stack); n > 0 && stack[n-1].key {
+				style = c.theme.Key
+				stack[n-1].key = false
+			} else {
+				style = c.theme.String
+				jsonColorValueDone(stack)
+			}
+		case json.Number:
+			style = c.theme.Number
+			jsonColorValueDone(stack)
+		case bool:
+			style = c.theme.Bool
+			jsonColorValueDone(stack)
+		case nil:
+			style = c.theme.Null
+			jsonColorValueDone(stack)
+		}
+		c.emit(lit, style)
+	}
+
+	var e *ParseError
+	if errors.As(parseErr, &e) {
+		// Everything up to the offending character, which gets highlighted. Some
+		// encoding/json versions put Offset inside a multi-byte character.
+		if k := int(e.Offset - src.base); k > 0 && k < len(src.rest()) && src.rest()[k]&0xC0 == 0x80 {
+			e.Offset -= int64(k - runeStart(src.rest()[:k]))
+		}
+		before := src.take(e.Offset)
+		c.emitSeparators(string(before))
+		e.Line, e.Column = c.line, c.column
+		_, size := utf8.DecodeRune(src.rest())
+		if size == 0 {
+			c.current.WriteString(c.theme.Error(" ")) // Input ended early
+			c.started = true
+		} else {
+			c.emit(string(src.take(e.Offset+int64(size))), c.theme.Error)
+		}
+	}
+	// Trailing whitespace, or whatever follows an error, as is
+	c.emitSeparators(string(src.rest()))
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.r.Read(buf)
+		c.emitSeparators(string(buf[:n]))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.finish()
+			return wrapErr(ErrFileRead, err)
+		}
+	}
+	if err := c.finish(); err != nil {
+		return err
+	}
+	return parseErr
+}
+
+// Marks the end of a value in the innermost container, after which an object expects a
+// key. Internal helper function.
+func jsonColorValueDone(stack []jsonColorFrame) {
+	if n := len(stack); n > 0 && stack[n-1].object {
+		stack[n-1].key = true
+	}
+}
+
+// jsonColorFrame tracks an open JSON array or object while colorizing
+type jsonColorFrame struct {
+	object bool
+	key    bool // Next string is an object key
+}
+
+// jsonSourceTee keeps the bytes the decoder has read but that haven't been printed yet,
+// so each token can be printed exactly as it appears in the source.
+type jsonSourceTee struct {
+	r    io.Reader
+	buf  []byte
+	pos  int   // Start of the bytes not taken yet
+	base int64 // Input offset of buf[pos]
+}
+
+func (t *jsonSourceTee) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if t.pos > len(t.buf)/2 {
+		// Drop the taken bytes once they are most of buf, rather than on every take
+		t.buf = t.buf[:copy(t.buf, t.buf[t.pos:])]
+		t.pos = 0
+	}
+	t.buf = append(t.buf, p[:n]...)
+	return n, err
+}
+
+// Returns the source bytes up to given input offset that haven't been taken yet. The
+// slice is only good until the next Read.
+func (t *jsonSourceTee) take(offset int64) []byte {
+	n := int(offset - t.base)
+	if n <= 0 {
+		return nil
+	}
+	n = min(n, len(t.buf)-t.pos)
+	chunk := t.buf[t.pos : t.pos+n]
+	t.pos += n
+	t.base += int64(n)
+	return chunk
+}
+
+// Returns the source bytes read but not taken yet
+func (t *jsonSourceTee) rest() []byte {
+	return t.buf[t.pos:]
+}
+
+// jsonColorizer assembles styled text into lines for a colorLineWriter, keeping track of
+// the source position as it goes.
+type jsonColorizer struct {
+	out     *colorLineWriter
+	theme   *Theme
+	current strings.Builder
+	started bool // Current line has any content
+	line    int  // 1-based source position of the next character
+	column  int
+}
+
+// Adds given text in given style, splitting it into lines
+func (c *jsonColorizer) emit(text string, style StyleFunc) {
+	if c.line == 0 {
+		c.line, c.column = 1, 1
+	}
+	for {
+		piece, rest, found := strings.Cut(text, "\n")
+		piece = strings.TrimSuffix(piece, "\r")
+		if piece != "" {
+			if strings.TrimSpace(piece) == "" {
+				c.current.WriteString(piece) // Don't style plain indentation
+			} else {
+				c.current.WriteString(style(piece))
+			}
+			c.started = true
+			c.column += utf8.RuneCountInString(piece)
+		}
+		if !found {
+			return
+		}
+		c.out.writeLine(c.current.String())
+		c.current.Reset()
+		c.started = false
+		c.line++
+		c.column = 1
+		text = rest
+	}
+}
+
+// Adds whitespace and punctuation between tokens
+func (c *jsonColorizer) emitSeparators(text string) {
+	c.emit(text, c.theme.Plain)
+}
+
+// Writes out the last line, unless it's empty because the input ended in a line break.
+// Returns write error, if any.
+func (c *jsonColorizer) finish() error {
+	if c.started {
+		c.out.writeLine(c.current.String())
+	}
+	return c.out.flush()
+}
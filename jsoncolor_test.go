@@ -0,0 +1,109 @@
+package utl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// Returns a theme that wraps each kind of token in a tag, like <k>"key"</k>, leaving
+// punctuation and whitespace as is
+func tagTheme() *Theme {
+	tag := func(name string) StyleFunc {
+		return func(a ...interface{}) string { return "<" + name + ">" + fmt.Sprint(a...) + "</" + name + ">" }
+	}
+	plain := func(a ...interface{}) string { return fmt.Sprint(a...) }
+	return &Theme{
+		Name: "tags", Key: tag("k"), String: tag("s"), Number: tag("n"), Bool: tag("b"),
+		Null: tag("z"), Anchor: tag("a"), Comment: tag("c"), Document: tag("d"), Plain: plain,
+		LineNumber: tag("l"), Highlight: tag("h"), Error: tag("e"),
+	}
+}
+
+func TestPrintJsonStreamColor(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"scalars", `{"a": [1, -2.5e3, true, null, "s"]}`,
+			`{<k>"a"</k>: [<n>1</n>, <n>-2.5e3</n>, <b>true</b>, <z>null</z>, <s>"s"</s>]}` + "\n"},
+		{"layout kept", "{\n  \"a\" :\t{\"b\":\"c\"},\r\n  \"d\": []\n}\n",
+			"{\n  <k>\"a\"</k> :\t{<k>\"b\"</k>:<s>\"c\"</s>},\n  <k>\"d\"</k>: []\n}\n"},
+		{"string values in arrays", `[{"k": "v"}, "w"]`,
+			`[{<k>"k"</k>: <s>"v"</s>}, <s>"w"</s>]` + "\n"},
+		{"ndjson", "{\"a\":1}\n{\"b\":\"x\"}\n\"s\"\n",
+			"{<k>\"a\"</k>:<n>1</n>}\n{<k>\"b\"</k>:<s>\"x\"</s>}\n<s>\"s\"</s>\n"},
+		{"wide runes", `{"日本": "é"}`, `{<k>"日本"</k>: <s>"é"</s>}` + "\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := PrintJsonStreamColor(strings.NewReader(tt.src), WithWriter(&buf), WithTheme(tagTheme())); err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestPrintJsonStreamColorError(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		line   int
+		column int
+		want   string
+	}{
+		{"bad value", "{\n  \"a\": x,\n  \"b\": 1\n}", 2, 8,
+			"{\n  <k>\"a\"</k>: <e>x</e>,\n  \"b\": 1\n}\n"},
+		{"bad rune", `["é", ü]`, 1, 7, `[<s>"é"</s>, <e>ü</e>]` + "\n"},
+		{"ends early", `{"a": [1,`, 1, 10, `{<k>"a"</k>: [<n>1</n>,<e> </e>` + "\n"},
+		{"second ndjson value", "{\"a\":1}\n{\"b\" 2}\n", 2, 6,
+			"{<k>\"a\"</k>:<n>1</n>}\n{<k>\"b\"</k> <e>2</e>}\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := PrintJsonStreamColor(strings.NewReader(tt.src), WithWriter(&buf), WithTheme(tagTheme()))
+		var e *ParseError
+		if !errors.As(err, &e) {
+			t.Errorf("%s: error = %v, want *ParseError", tt.name, err)
+			continue
+		}
+		if e.Line != tt.line || e.Column != tt.column {
+			t.Errorf("%s: error at %d:%d, want %d:%d", tt.name, e.Line, e.Column, tt.line, tt.column)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestPrintJsonStreamColorLarge(t *testing.T) {
+	// Big enough for the source buffer to be compacted many times over, and read in
+	// small uneven pieces
+	var sb strings.Builder
+	sb.WriteString("[\n")
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&sb, "  {\"id\": %d, \"name\": \"item %d\", \"ok\": %t},\n", i, i, i%2 == 0)
+	}
+	sb.WriteString("  null\n]\n")
+	src := sb.String()
+	var buf bytes.Buffer
+	if err := PrintJsonStreamColor(iotest.HalfReader(strings.NewReader(src)), WithWriter(&buf), WithTheme(tagTheme())); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, tag := range []string{"k", "s", "n", "b", "z"} {
+		got = strings.NewReplacer("<"+tag+">", "", "</"+tag+">", "").Replace(got)
+	}
+	if got != src {
+		t.Errorf("output differs from input once tags are removed")
+	}
+	if want := `  {<k>"id"</k>: <n>19999</n>, <k>"name"</k>: <s>"item 19999"</s>, <k>"ok"</k>: <b>false</b>},`; !strings.Contains(buf.String(), want) {
+		t.Errorf("output lacks %s", want)
+	}
+}
@@ -0,0 +1,631 @@
+package utl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// JsonPathMatch is a single value matched by a JSONPath query, with its JSON Pointer path
+type JsonPathMatch struct {
+	Path  string
+	Value interface{}
+}
+
+// JsonPath is a compiled JSONPath expression. The supported subset covers the root ($),
+// child names (.name, ['name']), wildcards (.*, [*]), recursive descent (..), array
+// indexes and slices ([0], [-1], [1:5:2]), unions ([0,2], ['a','b']) and filters such as
+// [?(@.kind == 'Pod' && @.spec.replicas > 1)], [?@.name =~ '^prod-'] and [?(!@.deleted)].
+type JsonPath struct {
+	expr     string
+	segments []jpSegment
+}
+
+type jpSegment struct {
+	descendant bool
+	selectors  []jpSelector
+}
+
+type jpSelectorKind int
+
+const (
+	jpName jpSelectorKind = iota
+	jpWildcard
+	jpIndex
+	jpSlice
+	jpFilter
+)
+
+type jpSelector struct {
+	kind             jpSelectorKind
+	name             string
+	index            int
+	start, end, step *int
+	filter           func(node interface{}) bool
+}
+
+// Compiles given JSONPath expression. Returns error wrapping ErrInvalidPath if any.
+func CompileJsonPath(expr string) (*JsonPath, error) {
+	p := &jpParser{src: expr}
+	segments, err := p.parsePath()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPath, err)
+	}
+	return &JsonPath{expr: expr, segments: segments}, nil
+}
+
+// Same as CompileJsonPath but panics on error
+func MustCompileJsonPath(expr string) *JsonPath {
+	p, err := CompileJsonPath(expr)
+	if err != nil {
+		panic(err.Error())
+	}
+	return p
+}
+
+// Returns the source expression
+func (p *JsonPath) String() string {
+	return p.expr
+}
+
+// Runs query against obj, a tree produced by LoadFileJson, LoadFileYaml and friends.
+// Object members are visited in sorted key order, so results are deterministic.
+func (p *JsonPath) Query(obj interface{}) []JsonPathMatch {
+	nodes := []JsonPathMatch{{Path: "", Value: obj}}
+	for _, seg := range p.segments {
+		var next []JsonPathMatch
+		for _, node := range nodes {
+			if seg.descendant {
+				walkDescendants(node, func(m JsonPathMatch) {
+					next = append(next, seg.apply(m)...)
+				})
+			} else {
+				next = append(next, seg.apply(node)...)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// Compiles given JSONPath expression and runs it against obj.
+// Returns matched values with their paths, and error if expr is invalid.
+func JsonPathQuery(obj interface{}, expr string) ([]JsonPathMatch, error) {
+	p, err := CompileJsonPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Query(obj), nil
+}
+
+// Calls fn for node and every value nested within it, depth first. Internal helper function.
+func walkDescendants(node JsonPathMatch, fn func(JsonPathMatch)) {
+	fn(node)
+	for _, child := range jpChildren(node) {
+		walkDescendants(child, fn)
+	}
+}
+
+// Returns the direct children of an object or array node. Internal helper function.
+func jpChildren(node JsonPathMatch) (children []JsonPathMatch) {
+	switch value := node.Value.(type) {
+	case map[string]interface{}:
+		for _, k := range SortObjStringKeys(value) {
+			children = append(children, JsonPathMatch{node.Path + "/" + jsonPointerEscape(k), value[k]})
+		}
+	case []interface{}:
+		for i, v := range value {
+			children = append(children, JsonPathMatch{node.Path + "/" + strconv.Itoa(i), v})
+		}
+	}
+	return children
+}
+
+// Applies each of the segment's selectors to node. Internal helper function.
+func (seg jpSegment) apply(node JsonPathMatch) (matches []JsonPathMatch) {
+	for _, sel := range seg.selectors {
+		switch sel.kind {
+		case jpName:
+			if m, ok := node.Value.(map[string]interface{}); ok {
+				if v, ok := m[sel.name]; ok {
+					matches = append(matches, JsonPathMatch{node.Path + "/" + jsonPointerEscape(sel.name), v})
+				}
+			}
+		case jpWildcard:
+			matches = append(matches, jpChildren(node)...)
+		case jpIndex:
+			if list, ok := node.Value.([]interface{}); ok {
+				i := sel.index
+				if i < 0 {
+					i += len(list)
+				}
+				if i >= 0 && i < len(list) {
+					matches = append(matches, JsonPathMatch{node.Path + "/" + strconv.Itoa(i), list[i]})
+				}
+			}
+		case jpSlice:
+			if list, ok := node.Value.([]interface{}); ok {
+				for _, i := range sliceIndexes(len(list), sel.start, sel.end, sel.step) {
+					matches = append(matches, JsonPathMatch{node.Path + "/" + strconv.Itoa(i), list[i]})
+				}
+			}
+		case jpFilter:
+			for _, child := range jpChildren(node) {
+				if sel.filter(child.Value) {
+					matches = append(matches, child)
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// Returns the indexes selected by slice [start:end:step] on an array of given length,
+// following Python slice semantics. Internal helper function.
+func sliceIndexes(length int, start, end, step *int) (indexes []int) {
+	st := 1
+	if step != nil {
+		st = *step
+	}
+	if st == 0 {
+		return nil
+	}
+	norm := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += length
+		}
+		return i
+	}
+	if st > 0 {
+		lo, hi := max(norm(start, 0), 0), min(norm(end, length), length)
+		for i := lo; i < hi; i += st {
+			indexes = append(indexes, i)
+		}
+	} else {
+		hi, lo := min(norm(start, length-1), length-1), max(norm(end, -length-1), -1)
+		for i := hi; i > lo; i += st {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// jpParser is a small recursive descent parser for JSONPath expressions
+type jpParser struct {
+	src string
+	pos int
+}
+
+func (p *jpParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %d in %q", fmt.Sprintf(format, args...), p.pos, p.src)
+}
+
+func (p *jpParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *jpParser) skipSpaces() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *jpParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// Same as consume, but only if s isn't followed by more of a name, so "true" doesn't
+// match the start of "trueish"
+func (p *jpParser) consumeWord(s string) bool {
+	if !strings.HasPrefix(p.src[p.pos:], s) {
+		return false
+	}
+	if end := p.pos + len(s); end < len(p.src) {
+		if c := p.src[end]; c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	p.pos += len(s)
+	return true
+}
+
+// Parses a full expression starting at $
+func (p *jpParser) parsePath() ([]jpSegment, error) {
+	p.skipSpaces()
+	if !p.consume("$") {
+		return nil, p.errorf("expected '$'")
+	}
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	return segments, nil
+}
+
+// Parses child and descendant segments until something else comes up
+func (p *jpParser) parseSegments() (segments []jpSegment, err error) {
+	for p.pos < len(p.src) {
+		var seg jpSegment
+		switch {
+		case p.consume(".."):
+			seg.descendant = true
+			if p.peek() == '[' {
+				seg.selectors, err = p.parseBracket()
+			} else {
+				seg.selectors, err = p.parseDotMember()
+			}
+		case p.consume("."):
+			seg.selectors, err = p.parseDotMember()
+		case p.peek() == '[':
+			seg.selectors, err = p.parseBracket()
+		default:
+			return segments, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// Parses the name or * following a dot
+func (p *jpParser) parseDotMember() ([]jpSelector, error) {
+	if p.consume("*") {
+		return []jpSelector{{kind: jpWildcard}}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.src) {
+		r := rune(p.src[p.pos])
+		if r != '_' && r != '-' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && r < 0x80 {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected member name")
+	}
+	return []jpSelector{{kind: jpName, name: p.src[start:p.pos]}}, nil
+}
+
+// Parses a comma separated list of selectors within [ ]
+func (p *jpParser) parseBracket() (selectors []jpSelector, err error) {
+	p.pos++ // Skip '['
+	for {
+		p.skipSpaces()
+		var sel jpSelector
+		switch c := p.peek(); {
+		case c == '*':
+			p.pos++
+			sel.kind = jpWildcard
+		case c == '\'' || c == '"':
+			sel.kind = jpName
+			if sel.name, err = p.parseQuoted(); err != nil {
+				return nil, err
+			}
+		case c == '?':
+			p.pos++
+			sel.kind = jpFilter
+			if sel.filter, err = p.parseOr(); err != nil {
+				return nil, err
+			}
+		case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+			if sel, err = p.parseIndexOrSlice(); err != nil {
+				return nil, err
+			}
+		case c == 0:
+			return nil, p.errorf("unexpected end of expression")
+		default:
+			return nil, p.errorf("unexpected %q in brackets", c)
+		}
+		selectors = append(selectors, sel)
+		p.skipSpaces()
+		if p.consume("]") {
+			return selectors, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+// Parses [n] or [start:end:step], any part of the latter being optional
+func (p *jpParser) parseIndexOrSlice() (sel jpSelector, err error) {
+	var parts [3]*int
+	n := 0
+	for ; n < 3; n++ {
+		p.skipSpaces()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			start := p.pos
+			p.pos++
+			for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+				p.pos++
+			}
+			i, err := strconv.Atoi(p.src[start:p.pos])
+			if err != nil {
+				return sel, p.errorf("invalid index %q", p.src[start:p.pos])
+			}
+			parts[n] = &i
+		}
+		p.skipSpaces()
+		if !p.consume(":") {
+			break
+		}
+	}
+	if n == 0 {
+		if parts[0] == nil {
+			return sel, p.errorf("expected index")
+		}
+		return jpSelector{kind: jpIndex, index: *parts[0]}, nil
+	}
+	return jpSelector{kind: jpSlice, start: parts[0], end: parts[1], step: parts[2]}, nil
+}
+
+// Parses a single or double quoted string, with backslash escapes
+func (p *jpParser) parseQuoted() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return sb.String(), nil
+		case c == '\\' && p.pos < len(p.src):
+			sb.WriteByte(p.src[p.pos])
+			p.pos++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// Filter grammar, lowest precedence first:
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | "(" or ")" | operand [ op operand ]
+//	operand = "@" relative-path | literal
+func (p *jpParser) parseOr() (func(interface{}) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); p.consume("||"); p.skipSpaces() {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(n interface{}) bool { return l(n) || right(n) }
+	}
+	return left, nil
+}
+
+func (p *jpParser) parseAnd() (func(interface{}) bool, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); p.consume("&&"); p.skipSpaces() {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(n interface{}) bool { return l(n) && right(n) }
+	}
+	return left, nil
+}
+
+func (p *jpParser) parseUnary() (func(interface{}) bool, error) {
+	p.skipSpaces()
+	if p.peek() == '!' && !strings.HasPrefix(p.src[p.pos:], "!=") {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n interface{}) bool { return !inner(n) }, nil
+	}
+	if p.consume("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if !p.consume(")") {
+			return nil, p.errorf("expected ')'")
+		}
+		return inner, nil
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "<", ">"} {
+		if !p.consume(op) {
+			continue
+		}
+		p.skipSpaces()
+		if op == "=~" {
+			if c := p.peek(); c != '\'' && c != '"' {
+				return nil, p.errorf("expected quoted regular expression")
+			}
+			pattern, err := p.parseQuoted()
+			if err != nil {
+				return nil, err
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, p.errorf("bad regular expression: %v", err)
+			}
+			return func(n interface{}) bool {
+				v, ok := left(n)
+				s, isStr := v.(string)
+				return ok && isStr && re.MatchString(s)
+			}, nil
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return func(n interface{}) bool {
+			l, lok := left(n)
+			r, rok := right(n)
+			return lok && rok && jpCompare(l, r, op)
+		}, nil
+	}
+	return func(n interface{}) bool { // No operator, so test for existence
+		_, ok := left(n)
+		return ok
+	}, nil
+}
+
+// Parses @-relative paths and literals into functions returning the value and whether it exists
+func (p *jpParser) parseOperand() (func(interface{}) (interface{}, bool), error) {
+	p.skipSpaces()
+	switch c := p.peek(); {
+	case c == '@':
+		p.pos++
+		segments, err := p.parseSegments()
+		if err != nil {
+			return nil, err
+		}
+		rel := &JsonPath{segments: segments}
+		return func(n interface{}) (interface{}, bool) {
+			matches := rel.Query(n)
+			if len(matches) == 0 {
+				return nil, false
+			}
+			return matches[0].Value, true
+		}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return func(interface{}) (interface{}, bool) { return s, true }, nil
+	}
+	for _, lit := range []struct {
+		word  string
+		value interface{}
+	}{{"true", true}, {"false", false}, {"null", nil}} {
+		if p.consumeWord(lit.word) {
+			v := lit.value
+			return func(interface{}) (interface{}, bool) { return v, true }, nil
+		}
+	}
+	start := p.pos
+	for c := p.peek(); c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E' || (c >= '0' && c <= '9'); c = p.peek() {
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("expected operand")
+	}
+	return func(interface{}) (interface{}, bool) { return f, true }, nil
+}
+
+// Compares two filter operands. Numbers compare numerically whatever their Go type, strings
+// lexically; anything else only supports == and !=. Internal helper function.
+func jpCompare(l, r interface{}, op string) bool {
+	lf, lNum := toFloat64(l)
+	rf, rNum := toFloat64(r)
+	ls, lStr := l.(string)
+	rs, rStr := r.(string)
+	var cmp int
+	switch {
+	case lNum && rNum:
+		cmp = compareOrdered(lf, rf)
+	case lStr && rStr:
+		cmp = strings.Compare(ls, rs)
+	default:
+		equal := reflect.DeepEqual(l, r)
+		return (op == "==" && equal) || (op == "!=" && !equal)
+	}
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// Returns -1, 0 or +1. Internal helper function.
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Returns v as float64 if it is any of the numeric types found in decoded JSON, YAML or
+// TOML trees. Internal helper function.
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
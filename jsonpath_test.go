@@ -0,0 +1,75 @@
+package utl
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJsonPathQuery(t *testing.T) {
+	doc := mustJsonObj(t, `{
+		"store": {
+			"book": [
+				{"category": "reference", "author": "Rees", "price": 8.95},
+				{"category": "fiction", "author": "Waugh", "price": 12.99, "isbn": "0-553"},
+				{"category": "fiction", "author": "Tolkien", "price": 22.99, "isbn": "0-395", "trueish": true}
+			],
+			"bicycle": {"color": "red", "price": 19.95}
+		}
+	}`)
+	tests := []struct {
+		expr  string
+		paths []string
+	}{
+		{"$", []string{""}},
+		{"$.store.bicycle.color", []string{"/store/bicycle/color"}},
+		{"$['store']['bicycle']", []string{"/store/bicycle"}},
+		{"$.store.book[0].author", []string{"/store/book/0/author"}},
+		{"$.store.book[-1].author", []string{"/store/book/2/author"}},
+		{"$.store.book[0,2].price", []string{"/store/book/0/price", "/store/book/2/price"}},
+		{"$.store.book[1:].author", []string{"/store/book/1/author", "/store/book/2/author"}},
+		{"$.store.book[::2].author", []string{"/store/book/0/author", "/store/book/2/author"}},
+		{"$.store.bicycle.*", []string{"/store/bicycle/color", "/store/bicycle/price"}},
+		{"$..isbn", []string{"/store/book/1/isbn", "/store/book/2/isbn"}},
+		{"$.store.book[?(@.price < 10)].author", []string{"/store/book/0/author"}},
+		{"$.store.book[?(@.category == 'fiction' && @.price > 20)].author", []string{"/store/book/2/author"}},
+		{"$.store.book[?@.author =~ '^T'].author", []string{"/store/book/2/author"}},
+		{"$.store.book[?(@.isbn)].author", []string{"/store/book/1/author", "/store/book/2/author"}},
+		{"$.store.book[?(!@.isbn)].author", []string{"/store/book/0/author"}},
+		{"$.store.book[?(@.trueish == true)].author", []string{"/store/book/2/author"}},
+		{"$.store.nope", nil},
+	}
+	for _, tt := range tests {
+		matches, err := JsonPathQuery(doc, tt.expr)
+		if err != nil {
+			t.Errorf("JsonPathQuery(%q) error = %v", tt.expr, err)
+			continue
+		}
+		var paths []string
+		for _, m := range matches {
+			paths = append(paths, m.Path)
+			if v, err := JsonPointerGet(doc, m.Path); err != nil || !reflect.DeepEqual(v, m.Value) {
+				t.Errorf("JsonPathQuery(%q): value at %q doesn't match its path", tt.expr, m.Path)
+			}
+		}
+		if !reflect.DeepEqual(paths, tt.paths) {
+			t.Errorf("JsonPathQuery(%q) paths = %q, want %q", tt.expr, paths, tt.paths)
+		}
+	}
+}
+
+func TestCompileJsonPathErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"store",
+		"$.store[",
+		"$.store['a",
+		"$[?(@.a == )]",
+		"$[?(@.a == trueish)]",
+		"$[?(@.a == nullx)]",
+	} {
+		if _, err := CompileJsonPath(expr); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("CompileJsonPath(%q) error = %v, want ErrInvalidPath", expr, err)
+		}
+	}
+}
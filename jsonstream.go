@@ -0,0 +1,180 @@
+package utl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Decodes a single JSON value from given reader. The value is still built in memory as a
+// whole, so use JsonStream to process large top-level arrays or objects piece by piece.
+// Like json.Unmarshal, anything other than whitespace after the value is an error.
+// Accepts WithOrderedMaps(). Returns JSON object and err if any.
+func DecodeJson(r io.Reader, opts ...LoadOption) (jsonObject interface{}, err error) {
+	if newLoadOptions(opts).ordered {
+		return decodeOrderedJsonSingle(r)
+	}
+	if err = decodeJsonSingle(r, &jsonObject, false); err != nil {
+		return nil, err
+	}
+	return jsonObject, nil
+}
+
+// Decodes exactly one JSON value from r into v, rejecting fields v has no place for if
+// strict is set. Internal helper function.
+func decodeJsonSingle(r io.Reader, v interface{}, strict bool) error {
+	var src bytes.Buffer
+	if strict {
+		r = io.TeeReader(r, &src) // To find an unknown field's path in
+	}
+	decoder := json.NewDecoder(r)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return jsonDecodeError(err, decoder.InputOffset(), src.Bytes(), v)
+	}
+	return checkJsonEnd(decoder)
+}
+
+// Returns error if decoder has anything but whitespace left. Internal helper function.
+func checkJsonEnd(decoder *json.Decoder) error {
+	offset := decoder.InputOffset()
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("invalid data after top-level value")
+		}
+		return jsonParseError(err, offset)
+	}
+	return nil
+}
+
+// JsonStream walks the top-level array or object of a JSON document one element at a
+// time, so arbitrarily large files can be processed in constant memory. Iterate with
+// Elems or Entries, then check Err, same as with bufio.Scanner. Anything other than
+// whitespace after the closing delimiter is an error.
+//
+//	s := utl.NewJsonStream(f)
+//	s.Entries()(func(key string, value interface{}) bool {
+//		...
+//		return true // false stops early
+//	})
+//	if err := s.Err(); err != nil { ... }
+//
+// With Go 1.23 or later the iterators also work with range, as in
+// for key, value := range s.Entries().
+type JsonStream struct {
+	decoder *json.Decoder
+	err     error
+}
+
+// Returns a new JsonStream reading from r
+func NewJsonStream(r io.Reader) *JsonStream {
+	return &JsonStream{decoder: json.NewDecoder(r)}
+}
+
+// Returns the first error hit while iterating, if any
+func (s *JsonStream) Err() error {
+	return s.err
+}
+
+// Returns an iterator over the elements of a top-level JSON array, with their index.
+// The iterator can only be consumed once.
+func (s *JsonStream) Elems() func(yield func(int, interface{}) bool) {
+	return func(yield func(int, interface{}) bool) {
+		if !s.open('[') {
+			return
+		}
+		for i := 0; s.decoder.More(); i++ {
+			var value interface{}
+			if err := s.decoder.Decode(&value); err != nil {
+				s.err = jsonParseError(err, s.decoder.InputOffset())
+				return
+			}
+			if !yield(i, value) {
+				return
+			}
+		}
+		s.close()
+	}
+}
+
+// Returns an iterator over the key/value pairs of a top-level JSON object, in file
+// order. The iterator can only be consumed once.
+func (s *JsonStream) Entries() func(yield func(string, interface{}) bool) {
+	return func(yield func(string, interface{}) bool) {
+		if !s.open('{') {
+			return
+		}
+		for s.decoder.More() {
+			tk, err := s.decoder.Token()
+			if err != nil {
+				s.err = jsonParseError(err, s.decoder.InputOffset())
+				return
+			}
+			key, _ := tk.(string) // Decoder guarantees object keys are strings
+			var value interface{}
+			if err := s.decoder.Decode(&value); err != nil {
+				s.err = jsonParseError(err, s.decoder.InputOffset())
+				return
+			}
+			if !yield(key, value) {
+				return
+			}
+		}
+		s.close()
+	}
+}
+
+// Consumes the opening delimiter, recording an error if it isn't the expected one
+func (s *JsonStream) open(want json.Delim) bool {
+	tk, err := s.decoder.Token()
+	if err != nil {
+		s.err = jsonParseError(err, s.decoder.InputOffset())
+		return false
+	}
+	if delim, ok := tk.(json.Delim); !ok || delim != want {
+		s.err = &ParseError{Offset: s.decoder.InputOffset() - 1, Message: fmt.Sprintf("expected top-level %q, got %v", want, tk), Err: ErrUnmarshal}
+		return false
+	}
+	return true
+}
+
+// Consumes the closing delimiter and checks nothing but whitespace follows it
+func (s *JsonStream) close() {
+	if _, err := s.decoder.Token(); err != nil {
+		s.err = jsonParseError(err, s.decoder.InputOffset())
+		return
+	}
+	s.err = checkJsonEnd(s.decoder)
+}
+
+// Calls fn for each element of the top-level JSON array read from r, stopping at the
+// first error. Returns error from decoding or from fn, if any.
+func StreamJsonArray(r io.Reader, fn func(index int, value interface{}) error) (err error) {
+	s := NewJsonStream(r)
+	s.Elems()(func(i int, v interface{}) bool {
+		err = fn(i, v)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.Err()
+}
+
+// Calls fn for each key/value pair of the top-level JSON object read from r, stopping
+// at the first error. Returns error from decoding or from fn, if any.
+func StreamJsonObject(r io.Reader, fn func(key string, value interface{}) error) (err error) {
+	s := NewJsonStream(r)
+	s.Entries()(func(k string, v interface{}) bool {
+		err = fn(k, v)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.Err()
+}
@@ -0,0 +1,98 @@
+package utl
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJson(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    interface{}
+		wantErr bool
+	}{
+		{` {"a":[1,"x"]} `, map[string]interface{}{"a": []interface{}{1.0, "x"}}, false},
+		{"null\n", nil, false},
+		{`{"a":1} {"b":2}`, nil, true},
+		{`{"a":1} x`, nil, true},
+		{`{"a":`, nil, true},
+		{``, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := DecodeJson(strings.NewReader(tt.src))
+		if (err != nil) != tt.wantErr || (!tt.wantErr && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("DecodeJson(%q) = %v, %v", tt.src, got, err)
+		}
+		if err != nil && !errors.Is(err, ErrUnmarshal) {
+			t.Errorf("DecodeJson(%q) error %v does not wrap ErrUnmarshal", tt.src, err)
+		}
+	}
+}
+
+func TestJsonStreamElems(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    []interface{}
+		wantErr bool
+	}{
+		{`[1, "a", {"b": null}]`, []interface{}{1.0, "a", map[string]interface{}{"b": nil}}, false},
+		{"[]\n", nil, false},
+		{`[1, 2] x`, []interface{}{1.0, 2.0}, true},
+		{`[1, 2`, []interface{}{1.0, 2.0}, true},
+		{`{"a": 1}`, nil, true},
+	}
+	for _, tt := range tests {
+		s := NewJsonStream(strings.NewReader(tt.src))
+		var got []interface{}
+		s.Elems()(func(i int, v interface{}) bool {
+			if i != len(got) {
+				t.Errorf("%q: index %d, want %d", tt.src, i, len(got))
+			}
+			got = append(got, v)
+			return true
+		})
+		if !reflect.DeepEqual(got, tt.want) || (s.Err() != nil) != tt.wantErr {
+			t.Errorf("Elems(%q) = %v, %v", tt.src, got, s.Err())
+		}
+	}
+}
+
+func TestJsonStreamEntries(t *testing.T) {
+	s := NewJsonStream(strings.NewReader(`{"b": 1, "a": [2], "c": 3}`))
+	var keys []string
+	s.Entries()(func(k string, v interface{}) bool {
+		keys = append(keys, k)
+		return k != "a" // Stop early
+	})
+	if s.Err() != nil || !reflect.DeepEqual(keys, []string{"b", "a"}) {
+		t.Errorf("keys = %v, %v, want file order up to the stop", keys, s.Err())
+	}
+
+	s = NewJsonStream(strings.NewReader(`{"a": 1} {"b": 2}`))
+	s.Entries()(func(string, interface{}) bool { return true })
+	if s.Err() == nil {
+		t.Errorf("data after the closing brace not rejected")
+	}
+}
+
+func TestStreamJsonArray(t *testing.T) {
+	stop := errors.New("stop")
+	var seen int
+	err := StreamJsonArray(strings.NewReader(`[1, 2, 3]`), func(i int, v interface{}) error {
+		seen++
+		if i == 1 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || seen != 2 {
+		t.Errorf("error = %v after %d elements, want fn's error after 2", err, seen)
+	}
+	err = StreamJsonObject(strings.NewReader(`{"a": 1, "b": `), func(string, interface{}) error { return nil })
+	var e *ParseError
+	if !errors.As(err, &e) {
+		t.Errorf("truncated object error = %v, want *ParseError", err)
+	}
+}
@@ -0,0 +1,203 @@
+package utl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOption tweaks how the Load* and decode functions interpret their input
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	strict     bool
+	ordered    bool
+	expand     bool
+	aliasLimit int
+}
+
+// Returns the loadOptions resulting from applying given opts. Internal helper function.
+func newLoadOptions(opts []LoadOption) loadOptions {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Reject input containing fields that don't exist in the target type. Only used by the
+// generic *As[T] loaders, where the error wraps ErrUnknownField and names the field, its
+// JSON Pointer path, i.e. /items/1/zzz, and its line. Types with their own UnmarshalJSON
+// or UnmarshalYAML decide for themselves what they accept.
+func WithStrict() LoadOption {
+	return func(o *loadOptions) { o.strict = true }
+}
+
+// Returns a *ParseError for an encoding/json error hit while decoding src into Go value
+// v, a pointer, flagging the error of a decoder set to DisallowUnknownFields as
+// ErrUnknownField, with the path and position of the field. Internal helper function.
+func jsonDecodeError(err error, offset int64, src []byte, v interface{}) error {
+	msg, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return jsonParseError(err, offset)
+	}
+	// The decoder doesn't say where the field is, and offset is past the whole value
+	e := &ParseError{Offset: -1, Message: "unknown field " + msg, Err: wrapErr(ErrUnknownField, err)}
+	var obj interface{}
+	if json.NewDecoder(bytes.NewReader(src)).Decode(&obj) == nil {
+		field, _ := strconv.Unquote(msg)
+		locateUnknownField(e, obj, src, reflect.TypeOf(v).Elem(), "json", field)
+	}
+	return e
+}
+
+// Returns a *ParseError for a yaml.v3 error hit while decoding src into Go value v, a
+// pointer, flagging the error of a decoder set to KnownFields as ErrUnknownField, with
+// the path and line of the first unknown field. Internal helper function.
+func yamlDecodeError(err error, src []byte, v interface{}) error {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		for i, msg := range typeErr.Errors {
+			field, found := yamlUnknownField(msg)
+			if !found {
+				continue
+			}
+			// Report the unknown field first, whatever else is wrong
+			typeErr.Errors[0], typeErr.Errors[i] = typeErr.Errors[i], typeErr.Errors[0]
+			parseErr := yamlParseError(err, src)
+			if e, ok := parseErr.(*ParseError); ok {
+				e.Err = wrapErr(ErrUnknownField, err)
+				var obj interface{}
+				if yaml.Unmarshal(src, &obj) == nil {
+					locateUnknownField(e, orderedToPlain(obj), src, reflect.TypeOf(v).Elem(), "yaml", field)
+				}
+			}
+			return parseErr
+		}
+	}
+	return yamlParseError(err, src)
+}
+
+// Returns the field named in a yaml.v3 KnownFields error message, like "line 4: field zzz
+// not found in type main.T", and whether msg is one. Internal helper function.
+func yamlUnknownField(msg string) (string, bool) {
+	_, rest, found := strings.Cut(msg, "field ")
+	if !found {
+		return "", false
+	}
+	field, _, found := strings.Cut(rest, " not found in type ")
+	return field, found
+}
+
+// Adds the JSON Pointer path of unknown field, as found in obj decoded from src into type
+// t, to e's message, and fills in its position if e has none yet. Internal helper function.
+func locateUnknownField(e *ParseError, obj interface{}, src []byte, t reflect.Type, tagName, field string) {
+	path := unknownFieldPath(obj, t, tagName, "", field)
+	if path == "" {
+		return
+	}
+	e.Message += " at " + path
+	if e.Line > 0 {
+		return
+	}
+	if file, err := parser.ParseBytes(src, 0); err == nil {
+		e.Line, e.Column = yamlPointerPosition(file, path) // JSON parses as YAML too
+	}
+}
+
+// Returns the JSON Pointer path of the first key named field in obj that type t has no
+// place for, walking structs, maps and slices, or "" if there's none. Internal helper
+// function.
+func unknownFieldPath(obj interface{}, t reflect.Type, tagName, path, field string) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	m, isMap := obj.(map[string]interface{})
+	switch t.Kind() {
+	case reflect.Struct:
+		if !isMap {
+			return "" // Scalars decoding into structs (time.Time etc.) are not our concern
+		}
+		fields := structFields(t, tagName)
+		for _, k := range SortObjStringKeys(m) {
+			ft, found := fields[k]
+			if !found && tagName == "json" {
+				for name, f := range fields { // encoding/json falls back to case-insensitive
+					if strings.EqualFold(name, k) {
+						ft, found = f, true
+						break
+					}
+				}
+			}
+			if !found {
+				if k == field {
+					return path + "/" + jsonPointerEscape(k)
+				}
+				continue
+			}
+			if p := unknownFieldPath(m[k], ft, tagName, path+"/"+jsonPointerEscape(k), field); p != "" {
+				return p
+			}
+		}
+	case reflect.Map:
+		for _, k := range SortObjStringKeys(m) {
+			if p := unknownFieldPath(m[k], t.Elem(), tagName, path+"/"+jsonPointerEscape(k), field); p != "" {
+				return p
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		list, _ := obj.([]interface{})
+		for i, v := range list {
+			if p := unknownFieldPath(v, t.Elem(), tagName, path+"/"+strconv.Itoa(i), field); p != "" {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// Returns the serialized field names of struct type t mapped to their types, flattening
+// embedded structs (json) and ",inline" fields (yaml). Internal helper function.
+func structFields(t reflect.Type, tagName string) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		inline := ft.Kind() == reflect.Struct &&
+			((tagName == "json" && f.Anonymous && name == "") ||
+				(tagName == "yaml" && strings.Contains(flags, "inline")))
+		if inline {
+			for k, v := range structFields(ft, tagName) {
+				if _, exists := fields[k]; !exists {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+			if tagName == "yaml" {
+				name = strings.ToLower(name)
+			}
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
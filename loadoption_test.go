@@ -0,0 +1,78 @@
+package utl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type strictItem struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+type strictConfig struct {
+	Title string                `json:"title" yaml:"title"`
+	Items []strictItem          `json:"items" yaml:"items"`
+	Meta  map[string]strictItem `json:"meta" yaml:"meta"`
+}
+
+func TestStrictUnknownFieldPath(t *testing.T) {
+	tests := []struct {
+		name, src, wantPath string
+		wantLine            int
+	}{
+		{"top level", `{"title":"x","zzz":1}`, "/zzz", 1},
+		{"array element", "{\n\"items\": [\n{\"name\":\"a\"},\n{\"name\":\"b\",\"zzz\":1}\n]\n}", "/items/1/zzz", 4},
+		{"map value", `{"meta":{"k~/":{"zzz":1}}}`, "/meta/k~0~1/zzz", 1},
+	}
+	for _, tt := range tests {
+		t.Run("json "+tt.name, func(t *testing.T) {
+			_, err := JsonBytesToJsonObjAs[strictConfig]([]byte(tt.src), WithStrict())
+			checkStrictError(t, err, tt.wantPath, tt.wantLine)
+		})
+	}
+
+	yamlTests := []struct {
+		name, src, wantPath string
+		wantLine            int
+	}{
+		{"top level", "title: x\nzzz: 1\n", "/zzz", 2},
+		{"array element", "items:\n  - name: a\n  - name: b\n    zzz: 1\n", "/items/1/zzz", 4},
+		{"map value", "meta:\n  k:\n    zzz: 1\n", "/meta/k/zzz", 3},
+	}
+	for _, tt := range yamlTests {
+		t.Run("yaml "+tt.name, func(t *testing.T) {
+			_, err := BytesToYamlObjectAs[strictConfig]([]byte(tt.src), WithStrict())
+			checkStrictError(t, err, tt.wantPath, tt.wantLine)
+		})
+	}
+}
+
+// Checks that err is a *ParseError for an unknown field at given path and line
+func checkStrictError(t *testing.T, err error, wantPath string, wantLine int) {
+	t.Helper()
+	var e *ParseError
+	if !errors.Is(err, ErrUnknownField) || !errors.As(err, &e) {
+		t.Fatalf("error = %v, want *ParseError wrapping ErrUnknownField", err)
+	}
+	if !strings.HasSuffix(e.Message, " at "+wantPath) {
+		t.Errorf("message %q does not end in path %s", e.Message, wantPath)
+	}
+	if e.Line != wantLine {
+		t.Errorf("line = %d, want %d", e.Line, wantLine)
+	}
+}
+
+func TestStrictKnownFields(t *testing.T) {
+	src := `{"title":"x","items":[{"name":"a"}],"meta":{"k":{"name":"b"}}}`
+	got, err := JsonBytesToJsonObjAs[strictConfig]([]byte(src), WithStrict())
+	if err != nil || got.Items[0].Name != "a" || got.Meta["k"].Name != "b" {
+		t.Errorf("got %+v, %v", got, err)
+	}
+	if _, err := BytesToYamlObjectAs[strictConfig]([]byte("title: x\nitems: [{name: a}]\n"), WithStrict()); err != nil {
+		t.Errorf("yaml error = %v", err)
+	}
+	if _, err := JsonBytesToJsonObjAs[strictConfig]([]byte(`{"zzz":1}`)); err != nil {
+		t.Errorf("non-strict error = %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package utl
+
+import "encoding/json"
+
+// ArrayStrategy selects how MergeDeep combines two arrays found at the same path
+type ArrayStrategy int
+
+const (
+	ArrayReplace    ArrayStrategy = iota // Array in y replaces the one in x (default)
+	ArrayAppend                          // Elements of y are appended to those of x
+	ArrayMergeByKey                      // Objects sharing the same key field are merged, see WithMergeKey
+)
+
+// MergeDelete can be used as a value in MergeDeep's y object to delete that key from x
+var MergeDelete interface{} = mergeDelete{}
+
+type mergeDelete struct{}
+
+// MergeOption tweaks how MergeDeep combines its two objects
+type MergeOption func(*mergeOptions)
+
+type mergeOptions struct {
+	arrays       ArrayStrategy
+	mergeKey     string
+	inPlace      bool
+	nullDeletes  bool
+	deleteMarker *string
+}
+
+// Combine arrays found at the same path using given strategy
+func WithArrayStrategy(strategy ArrayStrategy) MergeOption {
+	return func(o *mergeOptions) { o.arrays = strategy }
+}
+
+// Merge arrays of objects by matching their key field, i.e. "name". Objects in y with no
+// match in x are appended. Implies ArrayMergeByKey.
+func WithMergeKey(key string) MergeOption {
+	return func(o *mergeOptions) {
+		o.arrays = ArrayMergeByKey
+		o.mergeKey = key
+	}
+}
+
+// Modify x directly instead of returning a merged copy, which is cheaper for large trees
+func WithMergeInPlace() MergeOption {
+	return func(o *mergeOptions) { o.inPlace = true }
+}
+
+// Treat nil (JSON null, YAML ~) values in y as a request to delete the key from x
+func WithNullDeletes() MergeOption {
+	return func(o *mergeOptions) { o.nullDeletes = true }
+}
+
+// Treat string values in y equal to marker, i.e. "~delete", as a request to delete the
+// key from x. Useful for layered config files, where MergeDelete can't be expressed.
+func WithDeleteMarker(marker string) MergeOption {
+	return func(o *mergeOptions) { o.deleteMarker = &marker }
+}
+
+// Follow JSON Merge Patch semantics (RFC 7396): nulls delete and arrays are replaced
+func WithMergePatch() MergeOption {
+	return func(o *mergeOptions) {
+		o.nullDeletes = true
+		o.arrays = ArrayReplace
+	}
+}
+
+// Recursive merge of JSON object y onto object x. Nested objects are merged key by key,
+// arrays according to the ArrayStrategy, and any other value in y overwrites the one in x,
+// including nil unless WithNullDeletes() is given. By default neither x nor y is modified
+// and the returned object shares no data with them.
+func MergeDeep(x, y map[string]interface{}, opts ...MergeOption) map[string]interface{} {
+	o := mergeOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if x == nil {
+		x = map[string]interface{}{}
+	} else if !o.inPlace {
+		x = DeepCopy(x).(map[string]interface{})
+	}
+	return mergeMaps(x, y, &o)
+}
+
+// Applies JSON Merge Patch document patch to target, per RFC 7396. A patch that isn't an
+// object replaces target entirely. Returns the patched object; target is not modified.
+func JsonMergePatch(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return DeepCopy(patch)
+	}
+	targetMap, _ := target.(map[string]interface{})
+	return MergeDeep(targetMap, patchMap, WithMergePatch())
+}
+
+// Merges src onto dst in place. Internal helper function.
+func mergeMaps(dst, src map[string]interface{}, o *mergeOptions) map[string]interface{} {
+	for k, v := range src {
+		if o.isDelete(v) {
+			delete(dst, k)
+			continue
+		}
+		dst[k] = mergeValues(dst[k], v, o)
+	}
+	return dst
+}
+
+// Returns the result of merging src onto dst. Internal helper function.
+func mergeValues(dst, src interface{}, o *mergeOptions) interface{} {
+	switch s := src.(type) {
+	case map[string]interface{}:
+		if d, ok := dst.(map[string]interface{}); ok {
+			return mergeMaps(d, s, o)
+		}
+		return mergeMaps(map[string]interface{}{}, s, o) // Drops any delete markers within
+	case []interface{}:
+		d, ok := dst.([]interface{})
+		if !ok {
+			return DeepCopy(s)
+		}
+		switch o.arrays {
+		case ArrayAppend:
+			return append(d, DeepCopy(s).([]interface{})...)
+		case ArrayMergeByKey:
+			return mergeArraysByKey(d, s, o)
+		}
+		return DeepCopy(s)
+	}
+	return DeepCopy(src)
+}
+
+// Merges objects in src onto the objects in dst that share the same merge key value,
+// appending everything else. Internal helper function.
+func mergeArraysByKey(dst, src []interface{}, o *mergeOptions) []interface{} {
+	index := map[interface{}]int{}
+	for i, v := range dst {
+		if m, ok := v.(map[string]interface{}); ok {
+			if id, ok := m[o.mergeKey]; ok && isMergeKeyValue(id) {
+				index[id] = i
+			}
+		}
+	}
+	for _, v := range src {
+		if m, ok := v.(map[string]interface{}); ok {
+			if id, ok := m[o.mergeKey]; ok && isMergeKeyValue(id) {
+				if i, found := index[id]; found {
+					dst[i] = mergeValues(dst[i], m, o)
+					continue
+				}
+			}
+		}
+		dst = append(dst, mergeValues(nil, v, o))
+	}
+	return dst
+}
+
+// Returns true if v is one of the requested deletion markers. Internal helper function.
+func (o *mergeOptions) isDelete(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return o.nullDeletes
+	case mergeDelete:
+		return true
+	case string:
+		return o.deleteMarker != nil && value == *o.deleteMarker
+	}
+	return false
+}
+
+// Returns true if v is a scalar, and so can identify an object in ArrayMergeByKey mode.
+// Objects whose key is an object, array or null are never merged. Internal helper function.
+func isMergeKeyValue(v interface{}) bool {
+	switch v.(type) {
+	case string, bool, float64, float32, int, int64, int32, uint, uint64, uint32, json.Number:
+		return true
+	}
+	return false
+}
+
+// Returns a deep copy of given JSON/YAML object tree, as produced by LoadFileJson or
+// LoadFileYaml. Maps and slices are copied; scalar values are shared as-is.
+func DeepCopy(obj interface{}) interface{} {
+	switch value := obj.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			m[k] = DeepCopy(v)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, v := range value {
+			list[i] = DeepCopy(v)
+		}
+		return list
+	}
+	return obj
+}
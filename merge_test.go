@@ -0,0 +1,108 @@
+package utl
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Returns the JSON object in s, failing the test if it doesn't parse
+func mustJsonObj(t *testing.T, s string) interface{} {
+	t.Helper()
+	var obj interface{}
+	if err := json.Unmarshal([]byte(s), &obj); err != nil {
+		t.Fatalf("bad test JSON %s: %v", s, err)
+	}
+	return obj
+}
+
+func TestMergeDeep(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y string
+		opts []MergeOption
+		want string
+	}{
+		{"nested objects", `{"a":{"b":1,"c":2}}`, `{"a":{"c":3,"d":4}}`, nil, `{"a":{"b":1,"c":3,"d":4}}`},
+		{"scalar overwrites object", `{"a":{"b":1}}`, `{"a":5}`, nil, `{"a":5}`},
+		{"null kept by default", `{"a":1}`, `{"a":null}`, nil, `{"a":null}`},
+		{"null deletes", `{"a":1,"b":2}`, `{"a":null}`, []MergeOption{WithNullDeletes()}, `{"b":2}`},
+		{"delete marker", `{"a":1,"b":2}`, `{"b":"~delete"}`, []MergeOption{WithDeleteMarker("~delete")}, `{"a":1}`},
+		{"arrays replaced", `{"a":[1,2]}`, `{"a":[3]}`, nil, `{"a":[3]}`},
+		{"arrays appended", `{"a":[1,2]}`, `{"a":[3]}`, []MergeOption{WithArrayStrategy(ArrayAppend)}, `{"a":[1,2,3]}`},
+		{
+			"arrays merged by key",
+			`{"l":[{"name":"a","v":1},{"name":"b","v":2}]}`,
+			`{"l":[{"name":"b","v":3},{"name":"c","v":4}]}`,
+			[]MergeOption{WithMergeKey("name")},
+			`{"l":[{"name":"a","v":1},{"name":"b","v":3},{"name":"c","v":4}]}`,
+		},
+		{
+			"object and null merge keys never match",
+			`{"l":[{"id":{"x":1},"v":1},{"id":null,"v":2}]}`,
+			`{"l":[{"id":{"x":1},"v":3},{"id":null,"v":4}]}`,
+			[]MergeOption{WithMergeKey("id")},
+			`{"l":[{"id":{"x":1},"v":1},{"id":null,"v":2},{"id":{"x":1},"v":3},{"id":null,"v":4}]}`,
+		},
+		{"merge patch", `{"a":"b","c":{"d":"e","f":"g"}}`, `{"a":"z","c":{"f":null}}`, []MergeOption{WithMergePatch()}, `{"a":"z","c":{"d":"e"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := mustJsonObj(t, tt.x).(map[string]interface{})
+			before := DeepCopy(x)
+			got := MergeDeep(x, mustJsonObj(t, tt.y).(map[string]interface{}), tt.opts...)
+			if want := mustJsonObj(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(x, before) {
+				t.Errorf("x was modified: %v", x)
+			}
+		})
+	}
+}
+
+func TestMergeDeepUnhashableKey(t *testing.T) {
+	// yaml.v3 decodes nested mappings under interface{} as map[interface{}]interface{}
+	id := map[interface{}]interface{}{"x": 1}
+	x := map[string]interface{}{"l": []interface{}{map[string]interface{}{"id": id, "v": 1}}}
+	y := map[string]interface{}{"l": []interface{}{map[string]interface{}{"id": id, "v": 2}}}
+	got := MergeDeep(x, y, WithMergeKey("id"))
+	if n := len(got["l"].([]interface{})); n != 2 {
+		t.Errorf("got %d elements, want 2 unmerged", n)
+	}
+}
+
+func TestMergeDeepInPlace(t *testing.T) {
+	x := map[string]interface{}{"a": 1.0}
+	MergeDeep(x, map[string]interface{}{"b": 2.0}, WithMergeInPlace())
+	if x["b"] != 2.0 {
+		t.Errorf("x not modified in place: %v", x)
+	}
+}
+
+func TestJsonMergePatch(t *testing.T) {
+	// Examples from RFC 7396, Appendix A
+	tests := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got := JsonMergePatch(mustJsonObj(t, tt.target), mustJsonObj(t, tt.patch))
+		if want := mustJsonObj(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("JsonMergePatch(%s, %s) = %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
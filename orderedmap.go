@@ -0,0 +1,361 @@
+package utl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OrderedMap is a string keyed map that remembers insertion order. Loaders given the
+// WithOrderedMaps() option decode objects into *OrderedMap instead of
+// map[string]interface{}, and its JSON and YAML marshalers write keys back in the same
+// order, so output diffs cleanly against the source file. Nested objects are *OrderedMap
+// as well. The other object helpers in this package expect plain maps, see ToMap.
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// Returns a new, empty OrderedMap
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{values: map[string]interface{}{}}
+}
+
+// Decode objects into *OrderedMap instead of map[string]interface{}, so key order
+// survives a load and save round trip. Ignored by the generic *As[T] loaders.
+func WithOrderedMaps() LoadOption {
+	return func(o *loadOptions) { o.ordered = true }
+}
+
+// Sets key to value. A new key goes at the end, an existing one keeps its position.
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if m.values == nil {
+		m.values = map[string]interface{}{}
+	}
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Returns the value for key, and whether it exists
+func (m *OrderedMap) Get(key string) (value interface{}, ok bool) {
+	value, ok = m.values[key]
+	return value, ok
+}
+
+// Removes key, if present
+func (m *OrderedMap) Delete(key string) {
+	if _, exists := m.values[key]; !exists {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Returns the keys in insertion order. The slice must not be modified.
+func (m *OrderedMap) Keys() []string {
+	return m.keys
+}
+
+// Returns the number of keys
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// Returns an iterator over the key/value pairs in insertion order
+func (m *OrderedMap) Entries() func(yield func(string, interface{}) bool) {
+	return func(yield func(string, interface{}) bool) {
+		for _, k := range m.keys {
+			if !yield(k, m.values[k]) {
+				return
+			}
+		}
+	}
+}
+
+// Returns a plain map[string]interface{} copy, converting nested OrderedMaps too, for use
+// with MergeDeep, DiffObjects, JsonPointerGet and the other object helpers.
+func (m *OrderedMap) ToMap() map[string]interface{} {
+	return orderedToPlain(m).(map[string]interface{})
+}
+
+// Recursively converts *OrderedMap values within obj, and the map[interface{}]interface{}
+// yaml.v3 decodes mappings with non-string keys into, to map[string]interface{}, so the
+// helpers that expect plain maps can walk them. Only the containers on the way to a
+// converted value are copied; obj itself is never modified. Internal helper function.
+func orderedToPlain(obj interface{}) interface{} {
+	plain, _ := toPlainMaps(obj)
+	return plain
+}
+
+// Worker for orderedToPlain. Also returns whether anything had to be converted.
+func toPlainMaps(obj interface{}) (interface{}, bool) {
+	switch value := obj.(type) {
+	case *OrderedMap:
+		if value == nil {
+			return map[string]interface{}(nil), true
+		}
+		plain := make(map[string]interface{}, value.Len())
+		for _, k := range value.keys {
+			plain[k], _ = toPlainMaps(value.values[k])
+		}
+		return plain, true
+	case map[interface{}]interface{}:
+		plain := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			plain[fmt.Sprint(k)], _ = toPlainMaps(v)
+		}
+		return plain, true
+	case map[string]interface{}:
+		var plain map[string]interface{}
+		for k, v := range value {
+			if pv, changed := toPlainMaps(v); changed {
+				if plain == nil {
+					plain = make(map[string]interface{}, len(value))
+					for k2, v2 := range value {
+						plain[k2] = v2
+					}
+				}
+				plain[k] = pv
+			}
+		}
+		if plain != nil {
+			return plain, true
+		}
+	case []interface{}:
+		var list []interface{}
+		for i, v := range value {
+			if pv, changed := toPlainMaps(v); changed {
+				if list == nil {
+					list = append([]interface{}{}, value...)
+				}
+				list[i] = pv
+			}
+		}
+		if list != nil {
+			return list, true
+		}
+	}
+	return obj, false
+}
+
+// Implements json.Marshaler, writing keys in insertion order. A nil map is written as null.
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyBytes, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		valueBytes, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyBytes)
+		buf.WriteByte(':')
+		buf.Write(valueBytes)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Implements json.Unmarshaler, keeping keys in document order
+func (m *OrderedMap) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	value, err := decodeOrderedJson(decoder)
+	if err != nil {
+		return err
+	}
+	om, ok := value.(*OrderedMap)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal %T into OrderedMap", value)
+	}
+	*m = *om
+	return nil
+}
+
+// Implements yaml.Marshaler, writing keys in insertion order. A nil map is written as null.
+func (m *OrderedMap) MarshalYAML() (interface{}, error) {
+	if m == nil {
+		return nil, nil
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, k := range m.keys {
+		var keyNode, valueNode yaml.Node
+		if err := keyNode.Encode(k); err != nil {
+			return nil, err
+		}
+		if err := valueNode.Encode(m.values[k]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &keyNode, &valueNode)
+	}
+	return node, nil
+}
+
+// Implements yaml.Unmarshaler, keeping keys in document order
+func (m *OrderedMap) UnmarshalYAML(node *yaml.Node) error {
+	value, err := yamlNodeToOrdered(node)
+	if err != nil {
+		return err
+	}
+	om, ok := value.(*OrderedMap)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal %s into OrderedMap", node.ShortTag())
+	}
+	*m = *om
+	return nil
+}
+
+// Decodes the next JSON value from decoder, with objects as *OrderedMap. Internal helper.
+func decodeOrderedJson(decoder *json.Decoder) (interface{}, error) {
+	tk, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tk {
+	case json.Delim('{'):
+		m := NewOrderedMap()
+		for decoder.More() {
+			keyTk, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedJson(decoder)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(keyTk.(string), value)
+		}
+		_, err = decoder.Token() // Closing '}'
+		return m, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := decodeOrderedJson(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = decoder.Token() // Closing ']'
+		return list, err
+	}
+	return tk, nil
+}
+
+// Decodes exactly one JSON value from r, with objects as *OrderedMap. Internal helper.
+func decodeOrderedJsonSingle(r io.Reader) (interface{}, error) {
+	decoder := json.NewDecoder(r)
+	value, err := decodeOrderedJson(decoder)
+	if err != nil {
+		return nil, jsonParseError(err, decoder.InputOffset())
+	}
+	if err = checkJsonEnd(decoder); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Converts a yaml.v3 node tree into an object with mappings as *OrderedMap, following
+// aliases and "<<" merge keys the same way yaml.Unmarshal does. Returns error wrapping
+// ErrAliasLimit if aliases expand to more than DefaultAliasLimit nodes, as an alias bomb
+// would. Internal helper function.
+func yamlNodeToOrdered(node *yaml.Node) (interface{}, error) {
+	c := &orderedConverter{limit: DefaultAliasLimit}
+	return c.convert(node, false)
+}
+
+// orderedConverter does the work of yamlNodeToOrdered, counting the nodes reached through
+// aliases the same way aliasExpander does
+type orderedConverter struct {
+	limit int
+	count int
+}
+
+func (c *orderedConverter) convert(node *yaml.Node, viaAlias bool) (interface{}, error) {
+	if viaAlias && node.Kind != yaml.AliasNode {
+		if c.count++; c.count > c.limit {
+			return nil, fmt.Errorf("%w: aliases expand to more than %d nodes", ErrAliasLimit, c.limit)
+		}
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return c.convert(node.Content[0], viaAlias)
+	case yaml.AliasNode:
+		if node.Alias == nil {
+			return nil, fmt.Errorf("%w: unknown anchor %q", ErrAliasCycle, node.Value)
+		}
+		return c.convert(node.Alias, true)
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, child := range node.Content {
+			value, err := c.convert(child, viaAlias)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		m := NewOrderedMap()
+		var merged []*OrderedMap
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			value, err := c.convert(valueNode, viaAlias)
+			if err != nil {
+				return nil, err
+			}
+			if keyNode.Tag == "!!merge" {
+				switch v := value.(type) {
+				case *OrderedMap:
+					merged = append(merged, v)
+				case []interface{}:
+					for _, item := range v {
+						if om, ok := item.(*OrderedMap); ok {
+							merged = append(merged, om)
+						}
+					}
+				}
+				continue
+			}
+			var key interface{}
+			if err = keyNode.Decode(&key); err != nil {
+				return nil, err
+			}
+			m.Set(fmt.Sprintf("%v", key), value)
+		}
+		for _, src := range merged { // Explicit keys win, then earlier merge sources
+			for _, k := range src.keys {
+				if _, exists := m.values[k]; !exists {
+					m.Set(k, src.values[k])
+				}
+			}
+		}
+		return m, nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
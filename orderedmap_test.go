@@ -0,0 +1,141 @@
+package utl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestOrderedMapJsonRoundTrip(t *testing.T) {
+	tests := []string{
+		`{}`,
+		`{"z":1,"a":2,"m":3}`,
+		`{"z":{"y":[{"b":1,"a":2}],"x":null},"a":"s"}`,
+	}
+	for _, src := range tests {
+		var m OrderedMap
+		if err := json.Unmarshal([]byte(src), &m); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", src, err)
+			continue
+		}
+		got, err := json.Marshal(&m)
+		if err != nil {
+			t.Errorf("Marshal(%s) error = %v", src, err)
+			continue
+		}
+		if string(got) != src {
+			t.Errorf("round trip of %s gave %s", src, got)
+		}
+	}
+}
+
+func TestOrderedMapYamlRoundTrip(t *testing.T) {
+	src := "z: 1\na:\n    x: true\n    b: [1, 2]\nm: s\n"
+	var m OrderedMap
+	if err := yaml.Unmarshal([]byte(src), &m); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(m.Keys(), ","); got != "z,a,m" {
+		t.Errorf("keys = %s, want z,a,m", got)
+	}
+	out, err := yaml.Marshal(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "z: 1\na:\n    x: true\n    b:\n        - 1\n        - 2\nm: s\n"; string(out) != want {
+		t.Errorf("Marshal gave\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestOrderedMapYamlAliasBomb(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("a0: &a0 [x, x, x, x, x, x, x, x, x, x]\n")
+	for i := 1; i <= 6; i++ {
+		p := fmt.Sprintf("*a%d", i-1)
+		fmt.Fprintf(&b, "a%d: &a%d [%s]\n", i, i, strings.TrimSuffix(strings.Repeat(p+", ", 10), ", "))
+	}
+	_, err := BytesToYamlObject([]byte(b.String()), WithOrderedMaps())
+	if !errors.Is(err, ErrAliasLimit) {
+		t.Errorf("BytesToYamlObject(bomb, WithOrderedMaps()) error = %v, want ErrAliasLimit", err)
+	}
+	var m OrderedMap
+	if err = yaml.Unmarshal([]byte(b.String()), &m); !errors.Is(err, ErrAliasLimit) {
+		t.Errorf("yaml.Unmarshal(bomb, &OrderedMap) error = %v, want ErrAliasLimit", err)
+	}
+
+	// A few aliases well under the limit still expand
+	got, err := BytesToYamlObject([]byte("a: &a {x: 1}\nb: *a\nc: {<<: *a, y: 2}\n"), WithOrderedMaps())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := json.Marshal(got)
+	if want := `{"a":{"x":1},"b":{"x":1},"c":{"y":2,"x":1}}`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
+
+func TestOrderedMapSetDelete(t *testing.T) {
+	m := NewOrderedMap()
+	for _, k := range []string{"c", "a", "b"} {
+		m.Set(k, k)
+	}
+	m.Set("c", "again") // Keeps its position
+	m.Delete("a")
+	m.Delete("nope")
+	if got := strings.Join(m.Keys(), ","); got != "c,b" {
+		t.Errorf("keys = %s, want c,b", got)
+	}
+	if v, ok := m.Get("c"); !ok || v != "again" {
+		t.Errorf("Get(c) = %v, %v", v, ok)
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Error("deleted key still found")
+	}
+}
+
+func TestOrderedMapNil(t *testing.T) {
+	type holder struct {
+		M *OrderedMap `json:"m" yaml:"m"`
+	}
+	var m *OrderedMap
+	if b, err := m.MarshalJSON(); err != nil || string(b) != "null" {
+		t.Errorf("MarshalJSON() = %s, %v", b, err)
+	}
+	if v, err := m.MarshalYAML(); err != nil || v != nil {
+		t.Errorf("MarshalYAML() = %v, %v", v, err)
+	}
+	if b, err := json.Marshal(holder{}); err != nil || string(b) != `{"m":null}` {
+		t.Errorf("json.Marshal = %s, %v", b, err)
+	}
+	if b, err := yaml.Marshal(holder{}); err != nil || string(b) != "m: null\n" {
+		t.Errorf("yaml.Marshal = %q, %v", b, err)
+	}
+	if got := m.ToMap(); got != nil {
+		t.Errorf("ToMap() = %v, want nil", got)
+	}
+}
+
+func TestOrderedToPlain(t *testing.T) {
+	inner := NewOrderedMap()
+	inner.Set("k", []interface{}{map[interface{}]interface{}{1: "one"}})
+	plainOnly := map[string]interface{}{"a": []interface{}{1.0}}
+	obj := map[string]interface{}{"o": inner, "p": plainOnly}
+	want := map[string]interface{}{
+		"o": map[string]interface{}{"k": []interface{}{map[string]interface{}{"1": "one"}}},
+		"p": plainOnly,
+	}
+	if got := orderedToPlain(obj); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, ok := obj["o"].(*OrderedMap); !ok {
+		t.Error("input was modified")
+	}
+	if got, changed := toPlainMaps(plainOnly); changed || !reflect.DeepEqual(got, plainOnly) {
+		t.Errorf("plain tree reported as changed: %v", got)
+	}
+}
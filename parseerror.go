@@ -0,0 +1,331 @@
+package utl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/gookit/color"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseError is returned by every JSON, YAML and TOML loader in this package when the
+// input can't be decoded. It matches errors.Is(err, ErrUnmarshal), and also wraps the
+// underlying decoder error. Use PrintParseError to show it to users.
+type ParseError struct {
+	File    string // Empty when decoding a byte slice or reader
+	Line    int    // 1-based, 0 if unknown
+	Column  int    // 1-based, 0 if unknown
+	Offset  int64  // Byte offset into the input, -1 if unknown
+	Message string
+	Excerpt string // Offending source line, then a line with a caret under Column
+	Err     error
+}
+
+// Returns the error as "file:line:col: message", leaving out whatever is unknown
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	if e.File != "" {
+		sb.WriteString(e.File + ":")
+	}
+	if e.Line > 0 {
+		sb.WriteString(strconv.Itoa(e.Line) + ":")
+		if e.Column > 0 {
+			sb.WriteString(strconv.Itoa(e.Column) + ":")
+		}
+	} else if e.Offset >= 0 {
+		sb.WriteString("offset " + strconv.FormatInt(e.Offset, 10) + ":")
+	}
+	if sb.Len() > 0 {
+		sb.WriteString(" ")
+	}
+	sb.WriteString(e.Message)
+	return sb.String()
+}
+
+// Returns ErrUnmarshal and the underlying decoder error, for errors.Is and errors.As
+func (e *ParseError) Unwrap() []error {
+	return []error{ErrUnmarshal, e.Err}
+}
+
+// Most bytes of the offending line kept in Excerpt on either side of the error, so a huge
+// single line document, like minified JSON, doesn't end up in the error whole
+const excerptWindow = 120
+
+// Fills in Line and Column from Offset, if need be, and then Excerpt, using the source
+// that was being decoded. Internal helper function.
+func (e *ParseError) locate(src []byte) {
+	if e.Offset > int64(len(src)) {
+		return
+	}
+	e.locateReader(bytes.NewReader(src))
+}
+
+// Same as locate, reading the source from r only as far as the end of the offending line,
+// and holding on to no more of it than Excerpt needs. Internal helper function.
+func (e *ParseError) locateReader(r io.Reader) {
+	if e.Line == 0 && e.Offset < 0 {
+		return
+	}
+	br := bufio.NewReader(r)
+	var pos int64
+	line, runes := 1, 0 // Position of the next byte
+	var before []byte   // Tail of the current line, up to the error
+	var after []byte    // Rest of the line, from the error on
+	trimmed := false
+	for {
+		if e.Line == 0 && pos == e.Offset {
+			break
+		}
+		if e.Line > 0 && line == e.Line && (e.Column == 0 || runes >= e.Column-1) {
+			break
+		}
+		b, err := br.ReadByte()
+		if err != nil {
+			if e.Line > 0 && line == e.Line {
+				break // Column past the end of the line
+			}
+			return
+		}
+		pos++
+		if b == '\n' {
+			line, runes, before, trimmed = line+1, 0, before[:0], false
+			continue
+		}
+		if b&0xC0 != 0x80 { // Not a UTF-8 continuation byte
+			runes++
+		}
+		if before = append(before, b); len(before) > 2*excerptWindow {
+			before, trimmed = append(before[:0], before[len(before)-excerptWindow:]...), true
+		}
+	}
+	if e.Line == 0 {
+		// Some encoding/json versions put Offset inside a multi-byte character, so back
+		// up to where it starts
+		if next, _ := br.Peek(1); len(next) > 0 && next[0]&0xC0 == 0x80 {
+			if i := runeStart(before); i < len(before) {
+				e.Offset -= int64(len(before) - i)
+				after = append(after, before[i:]...)
+				before = before[:i]
+				runes--
+			}
+		}
+		e.Line, e.Column = line, runes+1
+	}
+
+	for len(after) < excerptWindow {
+		b, err := br.ReadByte()
+		if err != nil || b == '\n' {
+			break
+		}
+		after = append(after, b)
+	}
+	if len(before) > excerptWindow {
+		before, trimmed = before[len(before)-excerptWindow:], true
+	}
+	if trimmed {
+		// Drop any partial rune left at the start by the trimming
+		for len(before) > 0 && before[0]&0xC0 == 0x80 {
+			before = before[1:]
+		}
+		before = append([]byte("..."), before...)
+	}
+	prefix := []rune(strings.TrimRight(string(before), "\r"))
+	e.Excerpt = strings.TrimRight(string(before)+string(after), "\r")
+	if e.Column > 0 {
+		// Keep tabs in the padding so the caret lines up under tab-indented source
+		pad := make([]rune, len(prefix))
+		for i, c := range prefix {
+			if c == '\t' {
+				pad[i] = '\t'
+			} else {
+				pad[i] = ' '
+			}
+		}
+		e.Excerpt += "\n" + string(pad) + "^"
+	}
+}
+
+// Returns the index in b where a multi-byte UTF-8 character cut short at its end starts,
+// or len(b) if there is none. Internal helper function.
+func runeStart(b []byte) int {
+	for i := len(b) - 1; i >= 0 && len(b)-i <= utf8.UTFMax; i-- {
+		if b[i]&0xC0 == 0xC0 {
+			return i
+		} else if b[i]&0xC0 != 0x80 {
+			break
+		}
+	}
+	return len(b)
+}
+
+// Returns a *ParseError for an encoding/json error hit while decoding, or err wrapped as
+// ErrUnmarshal if it isn't a decoding problem. offset is where the decoder had got to, for
+// errors that don't carry one. Internal helper function.
+func jsonParseError(err error, offset int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+		if offset > 0 && !strings.HasPrefix(syntaxErr.Error(), "unexpected end") {
+			offset-- // Offset counts the offending byte itself
+		}
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	case err == io.EOF, errors.Is(err, io.ErrUnexpectedEOF):
+		err = io.ErrUnexpectedEOF
+	case errors.Is(err, ErrTooLarge):
+		return err
+	case offset < 0:
+		return wrapErr(ErrUnmarshal, err)
+	}
+	return &ParseError{Offset: offset, Message: strings.TrimPrefix(err.Error(), "json: "), Err: err}
+}
+
+var yamlLineRegex = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+var goyamlPosRegex = regexp.MustCompile(`^\[(\d+):(\d+)\] (.*)`)
+
+// Returns a *ParseError for a yaml.v3 or goccy error hit while decoding src. As yaml.v3
+// only reports lines, src is run through goccy's parser to pin down the column where
+// possible. Internal helper function.
+func yamlParseError(err error, src []byte) error {
+	if errors.Is(err, io.EOF) {
+		return wrapErr(ErrUnmarshal, err) // Empty input is not a syntax problem
+	}
+	e := &ParseError{Offset: -1, Err: err}
+	msg := goyaml.FormatError(err, false, false)
+	if m := goyamlPosRegex.FindStringSubmatch(msg); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+		e.Column, _ = strconv.Atoi(m[2])
+		e.Message = m[3]
+	} else {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+			msg = typeErr.Errors[0]
+		}
+		if m := yamlLineRegex.FindStringSubmatch(msg); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			msg = msg[len(m[0]):]
+		}
+		e.Message = strings.TrimPrefix(msg, "yaml: ")
+		if _, ok := err.(*yaml.TypeError); !ok && src != nil {
+			var obj interface{}
+			if goErr := goyaml.Unmarshal(src, &obj); goErr != nil {
+				if m := goyamlPosRegex.FindStringSubmatch(goyaml.FormatError(goErr, false, false)); m != nil {
+					e.Line, _ = strconv.Atoi(m[1])
+					e.Column, _ = strconv.Atoi(m[2])
+				}
+			}
+		}
+	}
+	e.locate(src)
+	return e
+}
+
+// Returns a *ParseError for a go-toml decoding error. Internal helper function.
+func tomlParseError(err error, src []byte) error {
+	var decodeErr *toml.DecodeError
+	if !errors.As(err, &decodeErr) {
+		return wrapErr(ErrUnmarshal, err)
+	}
+	e := &ParseError{Offset: -1, Message: decodeErr.Error(), Err: err}
+	e.Line, e.Column = decodeErr.Position()
+	e.locate(src)
+	return e
+}
+
+// Sets File on a *ParseError within err and, if it still lacks a position or excerpt,
+// fills them in from src. Returns err. Internal helper function.
+func withParseSource(err error, filePath string, src []byte) error {
+	var e *ParseError
+	if errors.As(err, &e) {
+		e.File = filePath
+		if e.Excerpt == "" && src != nil {
+			e.locate(src)
+		}
+	}
+	return err
+}
+
+// Same as withParseSource, for errors from decoding filePath as a stream. When err is a
+// *ParseError the file is read again, and decompressed if need be, but only as far as the
+// offending line. Internal helper function.
+func fileParseError(err error, filePath string) error {
+	var e *ParseError
+	if !errors.As(err, &e) {
+		return err
+	}
+	e.File = filePath
+	if e.Excerpt != "" {
+		return err
+	}
+	f, openErr := os.Open(filePath)
+	if openErr != nil {
+		return err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzipReader, gzErr := gzip.NewReader(br)
+		if gzErr != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		r = gzipReader
+	case bytes.HasPrefix(magic, zstdMagic):
+		zstdReader, zstdErr := zstd.NewReader(br)
+		if zstdErr != nil {
+			return err
+		}
+		defer zstdReader.Close()
+		r = zstdReader
+	}
+	e.locateReader(r)
+	return err
+}
+
+// Prints given error in color, to stderr unless WithWriter() says otherwise. A *ParseError
+// gets its location in yellow, the message in red, and the source excerpt with a red caret
+// under the offending column. Any other error is simply printed in red. Returns write
+// error, if any.
+func PrintParseError(err error, opts ...PrintOption) error {
+	o := newPrintOptions(append([]PrintOption{WithWriter(os.Stderr)}, opts...))
+	red, yel := codeStyle(color.FgLightRed), codeStyle(color.FgYellow)
+	gra, whi := codeStyle(color.FgDarkGray), codeStyle(color.FgWhite)
+	var sb strings.Builder
+	var e *ParseError
+	if !errors.As(err, &e) {
+		sb.WriteString(red(err.Error()) + "\n")
+	} else {
+		loc := strings.TrimSuffix(e.Error(), " "+e.Message)
+		if loc != e.Error() {
+			sb.WriteString(yel(loc) + " ")
+		}
+		sb.WriteString(red(e.Message) + "\n")
+		if source, caret, _ := strings.Cut(e.Excerpt, "\n"); e.Excerpt != "" {
+			gutter := fmt.Sprintf("%4d | ", e.Line)
+			sb.WriteString(gra(gutter) + whi(source) + "\n")
+			if caret != "" {
+				sb.WriteString(gra(PadSpaces(len(gutter)-2, 0)+"| ") + red(caret) + "\n")
+			}
+		}
+	}
+	_, werr := io.WriteString(o.writer, downgradeANSI(sb.String(), o.level))
+	return werr
+}
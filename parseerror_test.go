@@ -0,0 +1,96 @@
+package utl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorLocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		err     ParseError
+		line    int
+		column  int
+		excerpt string
+	}{
+		{"offset", "{\n  \"a\": x\n}", ParseError{Offset: 9}, 2, 8, "  \"a\": x\n       ^"},
+		{"offset at end", "{\"a\":", ParseError{Offset: 5}, 1, 6, "{\"a\":\n     ^"},
+		{"line and column", "a: 1\n\tb: [\n", ParseError{Offset: -1, Line: 2, Column: 5}, 2, 5, "\tb: [\n\t   ^"},
+		{"line only", "a: 1\nb: [\r\n", ParseError{Offset: -1, Line: 2}, 2, 0, "b: ["},
+		{"wide runes", "{\"é\": 日本 }", ParseError{Offset: 7}, 1, 7, "{\"é\": 日本 }\n      ^"},
+		{"inside a character", "[1, ü]", ParseError{Offset: 5}, 1, 5, "[1, ü]\n    ^"},
+		{"line past end", "a\n", ParseError{Offset: -1, Line: 5}, 5, 0, ""},
+		{"offset past end", "a", ParseError{Offset: 9}, 0, 0, ""},
+		{"long line", strings.Repeat("x", 1000) + "!" + strings.Repeat("y", 1000), ParseError{Offset: 1000}, 1, 1001,
+			"..." + strings.Repeat("x", 120) + "!" + strings.Repeat("y", 119) + "\n" + strings.Repeat(" ", 123) + "^"},
+	}
+	for _, tt := range tests {
+		e := tt.err
+		e.locate([]byte(tt.src))
+		if e.Line != tt.line || e.Column != tt.column || e.Excerpt != tt.excerpt {
+			t.Errorf("%s: got %d:%d %q, want %d:%d %q", tt.name, e.Line, e.Column, e.Excerpt, tt.line, tt.column, tt.excerpt)
+		}
+	}
+}
+
+func TestLoadFileJsonParseError(t *testing.T) {
+	dir := t.TempDir()
+	src := "[" + strings.Repeat(`{"k":"v"},`, 50000) + `{"k":}]`
+	plainPath := filepath.Join(dir, "x.json")
+	if err := os.WriteFile(plainPath, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(src))
+	zw.Close()
+	gzipPath := filepath.Join(dir, "x.json.gz")
+	if err := os.WriteFile(gzipPath, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, load := range []struct {
+		path string
+		fn   func(string, ...LoadOption) (interface{}, error)
+	}{{plainPath, LoadFileJson}, {gzipPath, LoadFileJsonGzip}} {
+		_, err := load.fn(load.path)
+		var e *ParseError
+		if !errors.As(err, &e) {
+			t.Fatalf("%s: error = %v, want *ParseError", load.path, err)
+		}
+		if e.File != load.path || e.Line != 1 || e.Column != len(src)-1 {
+			t.Errorf("%s: got %s:%d:%d", load.path, e.File, e.Line, e.Column)
+		}
+		source, caret, _ := strings.Cut(e.Excerpt, "\n")
+		if len(source) > 3+2*excerptWindow || !strings.HasSuffix(source, `{"k":}]`) || source[len(caret)-1] != '}' {
+			t.Errorf("%s: excerpt %q\n%q", load.path, source, caret)
+		}
+	}
+}
+
+func TestPrintParseError(t *testing.T) {
+	defer SetColorMode(GetColorMode())
+	SetColorMode(ColorNever)
+	_, err := JsonBytesToJsonObj([]byte("{\n\t\"a\": x\n}"))
+	var buf bytes.Buffer
+	if werr := PrintParseError(err, WithWriter(&buf)); werr != nil {
+		t.Fatal(werr)
+	}
+	e := err.(*ParseError)
+	want := e.Error() + "\n   2 | \t\"a\": x\n     | \t     ^\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	PrintParseError(ErrFileRead, WithWriter(&buf))
+	if buf.String() != ErrFileRead.Error()+"\n" {
+		t.Errorf("plain error printed as %q", buf.String())
+	}
+}
@@ -0,0 +1,215 @@
+package utl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JsonPatchError reports which operation of a JSON Patch document failed, and why.
+// Err wraps one of ErrInvalidPatch, ErrInvalidPath, ErrPathNotFound or ErrTestFailed.
+type JsonPatchError struct {
+	Index int    // Position of the failed operation within the patch document
+	Op    string // The operation, i.e. "replace"
+	Path  string // The operation's "path" member
+	Err   error
+}
+
+// Returns the error as "json patch operation <index> (<op> <path>): <reason>"
+func (e *JsonPatchError) Error() string {
+	return fmt.Sprintf("json patch operation %d (%s %s): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+// Returns Err, so errors.Is and errors.As see the sentinel it wraps
+func (e *JsonPatchError) Unwrap() error {
+	return e.Err
+}
+
+// Applies RFC 6902 JSON Patch document patch to obj, a tree produced by LoadFileJson,
+// LoadFileYaml and friends. The patch is itself a decoded JSON array of operation objects,
+// i.e. from LoadFileJson or DiffToJsonPatch. Supports add, remove, replace, move, copy and
+// test. Application is all-or-nothing: obj is never modified, and on success the patched
+// copy is returned. On failure obj is returned with a *JsonPatchError.
+func ApplyJsonPatch(obj interface{}, patch interface{}) (interface{}, error) {
+	ops, ok := patch.([]interface{})
+	if !ok {
+		return obj, fmt.Errorf("%w: document must be an array, not %T", ErrInvalidPatch, patch)
+	}
+	doc := DeepCopy(obj)
+	for i, item := range ops {
+		var err error
+		op, _ := item.(map[string]interface{})
+		opName, _ := op["op"].(string)
+		path, _ := op["path"].(string)
+		if doc, err = applyPatchOp(doc, op); err != nil {
+			return obj, &JsonPatchError{Index: i, Op: opName, Path: path, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+// Applies a single patch operation to doc, which may be modified. Internal helper function.
+func applyPatchOp(doc interface{}, op map[string]interface{}) (interface{}, error) {
+	if op == nil {
+		return doc, fmt.Errorf("%w: operation must be an object", ErrInvalidPatch)
+	}
+	opName, _ := op["op"].(string)
+	path, ok := op["path"].(string)
+	if !ok {
+		return doc, fmt.Errorf("%w: missing string member \"path\"", ErrInvalidPatch)
+	}
+	tokens, err := ParseJsonPointer(path)
+	if err != nil {
+		return doc, err
+	}
+	value, hasValue := op["value"]
+	var fromTokens []string
+	switch opName {
+	case "add", "replace", "test":
+		if !hasValue {
+			return doc, fmt.Errorf("%w: missing member \"value\"", ErrInvalidPatch)
+		}
+	case "move", "copy":
+		from, ok := op["from"].(string)
+		if !ok {
+			return doc, fmt.Errorf("%w: missing string member \"from\"", ErrInvalidPatch)
+		}
+		if fromTokens, err = ParseJsonPointer(from); err != nil {
+			return doc, err
+		}
+	case "remove":
+	default:
+		return doc, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, opName)
+	}
+
+	switch opName {
+	case "add":
+		return patchAdd(doc, tokens, DeepCopy(value))
+	case "remove":
+		doc, _, err = patchRemove(doc, tokens)
+		return doc, err
+	case "replace":
+		if doc, _, err = patchRemove(doc, tokens); err != nil {
+			return doc, err
+		}
+		return patchAdd(doc, tokens, DeepCopy(value))
+	case "move":
+		fromPath, toPath := JsonPointer(fromTokens...), JsonPointer(tokens...)
+		if fromPath == toPath {
+			_, err = JsonPointerGet(doc, fromPath)
+			return doc, err
+		}
+		if strings.HasPrefix(toPath, fromPath+"/") {
+			return doc, fmt.Errorf("%w: can't move %s into its own child", ErrInvalidPatch, fromPath)
+		}
+		if doc, value, err = patchRemove(doc, fromTokens); err != nil {
+			return doc, err
+		}
+		return patchAdd(doc, tokens, value)
+	case "copy":
+		if value, err = JsonPointerGet(doc, JsonPointer(fromTokens...)); err != nil {
+			return doc, err
+		}
+		return patchAdd(doc, tokens, DeepCopy(value))
+	}
+	// Must be "test"
+	current, err := JsonPointerGet(doc, path)
+	if err != nil {
+		return doc, err
+	}
+	if !valuesEqual(current, value) {
+		return doc, fmt.Errorf("%w: value at %s is %s, not %s", ErrTestFailed, path,
+			diffValueText(current), diffValueText(value))
+	}
+	return doc, nil
+}
+
+// Adds value at tokens: objects get the member set, arrays get the value inserted before the
+// given index, or appended for "-". Returns the updated doc. Internal helper function.
+func patchAdd(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	last := tokens[len(tokens)-1]
+	return pointerUpdate(doc, tokens[:len(tokens)-1], 0, func(parent interface{}) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[last] = value
+			return p, nil
+		case []interface{}:
+			idx, err := arrayIndex(last, len(p), true)
+			if err != nil {
+				return p, fmt.Errorf("%w: %s: %v", ErrPathNotFound, JsonPointer(tokens...), err)
+			}
+			p = append(p, nil)
+			copy(p[idx+1:], p[idx:])
+			p[idx] = value
+			return p, nil
+		}
+		return parent, pointerNotFound(tokens)
+	})
+}
+
+// Removes the value at tokens, which must exist. Returns the updated doc and the removed
+// value. Internal helper function.
+func patchRemove(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	var removed interface{}
+	last := tokens[len(tokens)-1]
+	doc, err := pointerUpdate(doc, tokens[:len(tokens)-1], 0, func(parent interface{}) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			v, ok := p[last]
+			if !ok {
+				return p, pointerNotFound(tokens)
+			}
+			removed = v
+			delete(p, last)
+			return p, nil
+		case []interface{}:
+			idx, err := arrayIndex(last, len(p), false)
+			if err != nil {
+				return p, fmt.Errorf("%w: %s: %v", ErrPathNotFound, JsonPointer(tokens...), err)
+			}
+			removed = p[idx]
+			return append(p[:idx], p[idx+1:]...), nil
+		}
+		return parent, pointerNotFound(tokens)
+	})
+	return doc, removed, err
+}
+
+// Walks existing values along tokens and replaces the final one with the result of fn,
+// writing any new array headers back into their parents. Internal helper function.
+func pointerUpdate(node interface{}, tokens []string, i int, fn func(interface{}) (interface{}, error)) (interface{}, error) {
+	if i == len(tokens) {
+		return fn(node)
+	}
+	tk := tokens[i]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[tk]
+		if !ok {
+			return node, pointerNotFound(tokens[:i+1])
+		}
+		child, err := pointerUpdate(child, tokens, i+1, fn)
+		if err != nil {
+			return node, err
+		}
+		n[tk] = child
+		return n, nil
+	case []interface{}:
+		idx, err := arrayIndex(tk, len(n), false)
+		if err != nil {
+			return node, fmt.Errorf("%w: %s: %v", ErrPathNotFound, JsonPointer(tokens[:i+1]...), err)
+		}
+		child, err := pointerUpdate(n[idx], tokens, i+1, fn)
+		if err != nil {
+			return node, err
+		}
+		n[idx] = child
+		return n, nil
+	}
+	return node, pointerNotFound(tokens[:i+1])
+}
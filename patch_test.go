@@ -0,0 +1,103 @@
+package utl
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyJsonPatch(t *testing.T) {
+	// Mostly the examples from RFC 6902, Appendix A
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"add to array end", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{
+			"move value",
+			`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"test passes", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"add nested object", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"ignore unknown members", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"foo":"bar","baz":"qux"}`},
+		{"escaped paths", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{"replace root", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustJsonObj(t, tt.doc)
+			got, err := ApplyJsonPatch(doc, mustJsonObj(t, tt.patch))
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if want := mustJsonObj(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(doc, mustJsonObj(t, tt.doc)) {
+				t.Errorf("input was modified: %v", doc)
+			}
+		})
+	}
+}
+
+func TestApplyJsonPatchErrors(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+		index            int
+		sentinel         error
+	}{
+		{"remove missing", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, 0, ErrPathNotFound},
+		{"add to missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, 0, ErrPathNotFound},
+		{"test fails", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, 0, ErrTestFailed},
+		{"test number against string", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`, 0, ErrTestFailed},
+		{"array index past end", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/5","value":"x"}]`, 0, ErrPathNotFound},
+		{"unknown op", `{}`, `[{"op":"frob","path":"/a"}]`, 0, ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, 0, ErrInvalidPatch},
+		{"bad pointer", `{}`, `[{"op":"add","path":"a","value":1}]`, 0, ErrInvalidPath},
+		{"move into own child", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, 0, ErrInvalidPatch},
+		{"fails after earlier ops", `{"a":1}`, `[{"op":"add","path":"/b","value":2},{"op":"remove","path":"/c"}]`, 1, ErrPathNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustJsonObj(t, tt.doc)
+			got, err := ApplyJsonPatch(doc, mustJsonObj(t, tt.patch))
+			var patchErr *JsonPatchError
+			if !errors.As(err, &patchErr) {
+				t.Fatalf("error = %v, want a *JsonPatchError", err)
+			}
+			if patchErr.Index != tt.index || !errors.Is(err, tt.sentinel) {
+				t.Errorf("error = %v (index %d), want index %d wrapping %v", err, patchErr.Index, tt.index, tt.sentinel)
+			}
+			if !reflect.DeepEqual(got, mustJsonObj(t, tt.doc)) {
+				t.Errorf("got %v, want the input unchanged", got)
+			}
+		})
+	}
+}
+
+func TestDiffToJsonPatchRoundTrip(t *testing.T) {
+	pairs := [][2]string{
+		{`{"a":1,"b":[1,2,3],"c":{"d":"e"}}`, `{"a":2,"b":[1],"c":{"f":"g"},"h":null}`},
+		{`[1,2]`, `[1,2,3,4]`},
+		{`{"a":"x"}`, `["y"]`},
+	}
+	for _, p := range pairs {
+		a, b := mustJsonObj(t, p[0]), mustJsonObj(t, p[1])
+		got, err := ApplyJsonPatch(a, DiffToJsonPatch(DiffObjects(a, b)))
+		if err != nil {
+			t.Errorf("%s -> %s: error = %v", p[0], p[1], err)
+			continue
+		}
+		if !reflect.DeepEqual(got, b) {
+			t.Errorf("%s -> %s: got %v", p[0], p[1], got)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package utl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Escapes a single JSON Pointer reference token, per RFC 6901. Internal helper function.
+func jsonPointerEscape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// Returns a JSON Pointer (RFC 6901) built from given unescaped reference tokens, i.e.
+// JsonPointer("spec", "a/b", "0") returns "/spec/a~1b/0". No tokens returns "", the root.
+func JsonPointer(tokens ...string) string {
+	var sb strings.Builder
+	for _, tk := range tokens {
+		sb.WriteString("/")
+		sb.WriteString(jsonPointerEscape(tk))
+	}
+	return sb.String()
+}
+
+// Splits given JSON Pointer into its unescaped reference tokens.
+// Returns error wrapping ErrInvalidPath if it isn't a valid pointer.
+func ParseJsonPointer(pointer string) (tokens []string, err error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: %q must be empty or start with '/'", ErrInvalidPath, pointer)
+	}
+	for _, tk := range strings.Split(pointer[1:], "/") {
+		for j := 0; j < len(tk); j++ {
+			if tk[j] == '~' && (j+1 == len(tk) || (tk[j+1] != '0' && tk[j+1] != '1')) {
+				return nil, fmt.Errorf("%w: %q has a bad '~' escape", ErrInvalidPath, pointer)
+			}
+		}
+		tokens = append(tokens, strings.ReplaceAll(strings.ReplaceAll(tk, "~1", "/"), "~0", "~"))
+	}
+	return tokens, nil
+}
+
+// Returns the value at given JSON Pointer within obj, a tree produced by LoadFileJson,
+// LoadFileYaml and friends. Returns error wrapping ErrPathNotFound if there's no such value.
+func JsonPointerGet(obj interface{}, pointer string) (value interface{}, err error) {
+	tokens, err := ParseJsonPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	value = obj
+	for i, tk := range tokens {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			v, ok := node[tk]
+			if !ok {
+				return nil, pointerNotFound(tokens[:i+1])
+			}
+			value = v
+		case []interface{}:
+			idx, err := arrayIndex(tk, len(node), false)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrPathNotFound, JsonPointer(tokens[:i+1]...), err)
+			}
+			value = node[idx]
+		default:
+			return nil, pointerNotFound(tokens[:i+1])
+		}
+	}
+	return value, nil
+}
+
+// Sets the value at given JSON Pointer within obj, creating missing intermediate objects.
+// An array index equal to the array's length, or "-", appends. Since the root itself may
+// be replaced, and arrays may grow, always use the returned object. Returns error if any.
+func JsonPointerSet(obj interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := ParseJsonPointer(pointer)
+	if err != nil {
+		return obj, err
+	}
+	return pointerSet(obj, tokens, 0, value)
+}
+
+// Removes the value at given JSON Pointer within obj. Array elements after a removed one
+// shift down. Always use the returned object. Returns error wrapping ErrPathNotFound
+// if there's no such value.
+func JsonPointerDelete(obj interface{}, pointer string) (interface{}, error) {
+	tokens, err := ParseJsonPointer(pointer)
+	if err != nil {
+		return obj, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return pointerDelete(obj, tokens, 0)
+}
+
+// Recursive worker for JsonPointerSet. Internal helper function.
+func pointerSet(node interface{}, tokens []string, i int, value interface{}) (interface{}, error) {
+	if i == len(tokens) {
+		return value, nil
+	}
+	tk := tokens[i]
+	switch n := node.(type) {
+	case nil:
+		child, err := pointerSet(nil, tokens, i+1, value)
+		if err != nil {
+			return node, err
+		}
+		return map[string]interface{}{tk: child}, nil
+	case map[string]interface{}:
+		child, err := pointerSet(n[tk], tokens, i+1, value)
+		if err != nil {
+			return node, err
+		}
+		n[tk] = child
+		return n, nil
+	case []interface{}:
+		idx, err := arrayIndex(tk, len(n), true)
+		if err != nil {
+			return node, fmt.Errorf("%w: %s: %v", ErrPathNotFound, JsonPointer(tokens[:i+1]...), err)
+		}
+		if idx == len(n) {
+			child, err := pointerSet(nil, tokens, i+1, value)
+			if err != nil {
+				return node, err
+			}
+			return append(n, child), nil
+		}
+		child, err := pointerSet(n[idx], tokens, i+1, value)
+		if err != nil {
+			return node, err
+		}
+		n[idx] = child
+		return n, nil
+	}
+	return node, fmt.Errorf("%w: %s: parent is a %T, not an object or array",
+		ErrPathNotFound, JsonPointer(tokens[:i+1]...), node)
+}
+
+// Recursive worker for JsonPointerDelete. Internal helper function.
+func pointerDelete(node interface{}, tokens []string, i int) (interface{}, error) {
+	tk := tokens[i]
+	last := i == len(tokens)-1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[tk]
+		if !ok {
+			return node, pointerNotFound(tokens[:i+1])
+		}
+		if last {
+			delete(n, tk)
+			return n, nil
+		}
+		child, err := pointerDelete(child, tokens, i+1)
+		if err != nil {
+			return node, err
+		}
+		n[tk] = child
+		return n, nil
+	case []interface{}:
+		idx, err := arrayIndex(tk, len(n), false)
+		if err != nil {
+			return node, fmt.Errorf("%w: %s: %v", ErrPathNotFound, JsonPointer(tokens[:i+1]...), err)
+		}
+		if last {
+			// Into a new slice, so other holders of n don't see its elements shift
+			list := make([]interface{}, 0, len(n)-1)
+			list = append(list, n[:idx]...)
+			return append(list, n[idx+1:]...), nil
+		}
+		child, err := pointerDelete(n[idx], tokens, i+1)
+		if err != nil {
+			return node, err
+		}
+		n[idx] = child
+		return n, nil
+	}
+	return node, pointerNotFound(tokens[:i+1])
+}
+
+// Converts JSON Pointer token tk into an index into an array of given length. If allowEnd
+// is true, "-" and length itself are accepted as the position just past the last element.
+// Internal helper function.
+func arrayIndex(tk string, length int, allowEnd bool) (int, error) {
+	if tk == "-" && allowEnd {
+		return length, nil
+	}
+	if tk == "" || (len(tk) > 1 && tk[0] == '0') || strings.TrimLeft(tk, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", tk)
+	}
+	idx, err := strconv.Atoi(tk)
+	if err != nil || idx > length || (idx == length && !allowEnd) {
+		return 0, fmt.Errorf("array index %s out of range", tk)
+	}
+	return idx, nil
+}
+
+// Returns an ErrPathNotFound error for given tokens. Internal helper function.
+func pointerNotFound(tokens []string) error {
+	return fmt.Errorf("%w: %s", ErrPathNotFound, JsonPointer(tokens...))
+}
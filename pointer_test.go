@@ -0,0 +1,149 @@
+package utl
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseJsonPointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{}, false},
+		{"/", []string{""}, false},
+		{"/a/b", []string{"a", "b"}, false},
+		{"/a~1b/m~0n", []string{"a/b", "m~n"}, false},
+		{"/~01", []string{"~1"}, false},
+		{"a", nil, true},
+		{"/a~", nil, true},
+		{"/a~2", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseJsonPointer(tt.pointer)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseJsonPointer(%q) error = %v, wantErr %v", tt.pointer, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseJsonPointer(%q) = %q, want %q", tt.pointer, got, tt.want)
+		}
+		if !tt.wantErr && JsonPointer(got...) != tt.pointer {
+			t.Errorf("JsonPointer(%q) = %q, want %q", got, JsonPointer(got...), tt.pointer)
+		}
+	}
+}
+
+func TestJsonPointerGet(t *testing.T) {
+	// Examples from RFC 6901, section 5
+	doc := mustJsonObj(t, `{"foo":["bar","baz"],"":0,"a/b":1,"c%d":2,"e^f":3,"g|h":4,"i\\j":5,"k\"l":6," ":7,"m~n":8}`)
+	tests := []struct {
+		pointer string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"/foo", `["bar","baz"]`, false},
+		{"/foo/0", `"bar"`, false},
+		{"/", "0", false},
+		{"/a~1b", "1", false},
+		{"/c%d", "2", false},
+		{"/m~0n", "8", false},
+		{"/ ", "7", false},
+		{"/foo/2", "", true},
+		{"/foo/01", "", true},
+		{"/foo/-", "", true},
+		{"/nope", "", true},
+		{"/foo/0/x", "", true},
+	}
+	for _, tt := range tests {
+		got, err := JsonPointerGet(doc, tt.pointer)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("JsonPointerGet(%q) error = %v, wantErr %v", tt.pointer, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			if !errors.Is(err, ErrPathNotFound) {
+				t.Errorf("JsonPointerGet(%q) error = %v, want ErrPathNotFound", tt.pointer, err)
+			}
+			continue
+		}
+		want := doc
+		if tt.want != "" {
+			want = mustJsonObj(t, tt.want)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("JsonPointerGet(%q) = %v, want %v", tt.pointer, got, want)
+		}
+	}
+}
+
+func TestJsonPointerSet(t *testing.T) {
+	tests := []struct {
+		doc, pointer, value, want string
+	}{
+		{`{"a":1}`, "/b", `2`, `{"a":1,"b":2}`},
+		{`{"a":1}`, "/a", `{"x":[]}`, `{"a":{"x":[]}}`},
+		{`{}`, "/a/b/c", `1`, `{"a":{"b":{"c":1}}}`},
+		{`{"l":[1,2]}`, "/l/0", `9`, `{"l":[9,2]}`},
+		{`{"l":[1,2]}`, "/l/-", `3`, `{"l":[1,2,3]}`},
+		{`{"l":[1,2]}`, "/l/2", `3`, `{"l":[1,2,3]}`},
+		{`{"a":1}`, "", `[1]`, `[1]`},
+	}
+	for _, tt := range tests {
+		got, err := JsonPointerSet(mustJsonObj(t, tt.doc), tt.pointer, mustJsonObj(t, tt.value))
+		if err != nil {
+			t.Errorf("JsonPointerSet(%s, %q) error = %v", tt.doc, tt.pointer, err)
+			continue
+		}
+		if want := mustJsonObj(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("JsonPointerSet(%s, %q) = %v, want %s", tt.doc, tt.pointer, got, tt.want)
+		}
+	}
+	if _, err := JsonPointerSet(mustJsonObj(t, `{"l":[1]}`), "/l/5", 1.0); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("setting past the end of an array: error = %v, want ErrPathNotFound", err)
+	}
+}
+
+func TestJsonPointerDelete(t *testing.T) {
+	tests := []struct {
+		doc, pointer, want string
+		wantErr            bool
+	}{
+		{`{"a":1,"b":2}`, "/a", `{"b":2}`, false},
+		{`{"a":{"b":1,"c":2}}`, "/a/b", `{"a":{"c":2}}`, false},
+		{`{"l":[1,2,3]}`, "/l/1", `{"l":[1,3]}`, false},
+		{`[1,2,3]`, "/2", `[1,2]`, false},
+		{`{"a":1}`, "/b", ``, true},
+		{`{"l":[1]}`, "/l/1", ``, true},
+	}
+	for _, tt := range tests {
+		got, err := JsonPointerDelete(mustJsonObj(t, tt.doc), tt.pointer)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("JsonPointerDelete(%s, %q) error = %v, wantErr %v", tt.doc, tt.pointer, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if want := mustJsonObj(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("JsonPointerDelete(%s, %q) = %v, want %s", tt.doc, tt.pointer, got, tt.want)
+		}
+	}
+}
+
+func TestJsonPointerDeleteKeepsCallerSlice(t *testing.T) {
+	list := []interface{}{"a", "b", "c"}
+	got, err := JsonPointerDelete(list, "/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []interface{}{"a", "b", "c"}; !reflect.DeepEqual(list, want) {
+		t.Errorf("caller's slice changed to %v", list)
+	}
+}
@@ -0,0 +1,474 @@
+package utl
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// SchemaViolation is a single JSON Schema validation failure. Path is the JSON Pointer of
+// the offending value, and SchemaPath that of the schema keyword it failed. Line and Column
+// are only set by ValidateSchemaBytes, and are 0 when unknown.
+type SchemaViolation struct {
+	Path       string
+	SchemaPath string
+	Message    string
+	Line       int
+	Column     int
+}
+
+// Returns the violation as "line:col: /path: message", or without the position if unknown
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	if v.Line > 0 {
+		return fmt.Sprintf("%d:%d: %s: %s", v.Line, v.Column, path, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", path, v.Message)
+}
+
+// JsonSchema is a JSON Schema (draft 2020-12) ready to validate object trees produced by
+// LoadFileJson, LoadFileYaml and friends. Supported keywords: type, enum, const, required,
+// properties, patternProperties, additionalProperties, minProperties, maxProperties,
+// items, prefixItems, minItems, maxItems, uniqueItems, minLength, maxLength, pattern,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, allOf, anyOf, oneOf,
+// not, if/then/else, and $ref to "#" pointers within the same schema document, which
+// covers $defs. Other keywords, such as format, are ignored.
+type JsonSchema struct {
+	root    interface{}
+	regexes map[string]*regexp.Regexp
+}
+
+// Prepares given schema object, typically from LoadFileJson or LoadFileYaml, for
+// validation. Returns error wrapping ErrInvalidQuery if a pattern doesn't compile.
+func CompileJsonSchema(schema interface{}) (*JsonSchema, error) {
+	s := &JsonSchema{root: orderedToPlain(schema), regexes: map[string]*regexp.Regexp{}}
+	var err error
+	walkSchemaPatterns(s.root, func(pattern string) {
+		if _, found := s.regexes[pattern]; found || err != nil {
+			return
+		}
+		re, reErr := regexp.Compile(pattern)
+		if reErr != nil {
+			err = fmt.Errorf("%w: schema pattern %q: %v", ErrInvalidQuery, pattern, reErr)
+			return
+		}
+		s.regexes[pattern] = re
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validates obj against the schema and returns every violation found, or none if valid
+func (s *JsonSchema) Validate(obj interface{}) []SchemaViolation {
+	v := &schemaValidator{schema: s, violations: []SchemaViolation{}}
+	v.validate(orderedToPlain(obj), s.root, "", "", 0)
+	return v.violations
+}
+
+// Compiles schema and validates obj against it. Returns the violations found, and error
+// if the schema itself is invalid.
+func ValidateSchema(obj, schema interface{}) ([]SchemaViolation, error) {
+	s, err := CompileJsonSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return s.Validate(obj), nil
+}
+
+// Decodes given YAML or JSON byte slice, validates it against schema, and fills in each
+// violation's Line and Column from the source. Returns the violations found, and error
+// if the source doesn't parse or the schema is invalid.
+func ValidateSchemaBytes(data []byte, schema interface{}) ([]SchemaViolation, error) {
+	obj, err := BytesToYamlObject(data)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := ValidateSchema(obj, schema)
+	if err != nil || len(violations) == 0 {
+		return violations, err
+	}
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return violations, nil // Positions are a nicety; the violations still stand
+	}
+	for i := range violations {
+		violations[i].Line, violations[i].Column = yamlPointerPosition(file, violations[i].Path)
+	}
+	return violations, nil
+}
+
+// Returns the source line and column of the value at given JSON Pointer in a parsed YAML
+// file, falling back to the nearest existing parent. Returns 0, 0 if not found.
+// Internal helper function.
+func yamlPointerPosition(file *ast.File, pointer string) (line, column int) {
+	tokens, err := ParseJsonPointer(pointer)
+	if err != nil {
+		return 0, 0
+	}
+	for n := len(tokens); n >= 0; n-- {
+		b := (&goyaml.PathBuilder{}).Root()
+		for _, tk := range tokens[:n] {
+			if idx, err := strconv.ParseUint(tk, 10, 32); err == nil && !strings.HasPrefix(tk, "+") {
+				b = b.Index(uint(idx))
+			} else {
+				b = b.Child(tk)
+			}
+		}
+		node, err := b.Build().FilterFile(file)
+		if err != nil || node == nil || node.GetToken() == nil {
+			continue
+		}
+		pos := node.GetToken().Position
+		return pos.Line, pos.Column
+	}
+	return 0, 0
+}
+
+// Calls fn with every "pattern" value and "patternProperties" key in schema document root,
+// visiting only subschemas, including those reached by $ref, so literal data under const,
+// enum, default and the like is left alone. Internal helper function.
+func walkSchemaPatterns(root interface{}, fn func(string)) {
+	visited := map[string]bool{}
+	var walk func(schema interface{})
+	walk = func(schema interface{}) {
+		s, ok := schema.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			fn(pattern)
+		}
+		if ref, ok := s["$ref"].(string); ok && !visited[ref] {
+			visited[ref] = true
+			if target, err := resolveSchemaRef(root, ref); err == nil {
+				walk(target)
+			}
+		}
+		for _, k := range SortObjStringKeys(s) {
+			switch k {
+			case "additionalProperties", "items", "not", "if", "then", "else", "contains", "propertyNames":
+				walk(s[k])
+			case "allOf", "anyOf", "oneOf", "prefixItems":
+				subs, _ := s[k].([]interface{})
+				for _, sub := range subs {
+					walk(sub)
+				}
+			case "properties", "patternProperties", "$defs", "definitions", "dependentSchemas":
+				subs, _ := s[k].(map[string]interface{})
+				for _, name := range SortObjStringKeys(subs) {
+					if k == "patternProperties" {
+						fn(name)
+					}
+					walk(subs[name])
+				}
+			}
+		}
+	}
+	walk(root)
+}
+
+// Most $ref hops followed without moving on to a nested value, which stops schemas such
+// as {"$ref": "#"} from looping forever. Hops that do move on don't count, as the data
+// itself bounds how deep those go.
+const maxSchemaRefDepth = 64
+
+type schemaValidator struct {
+	schema     *JsonSchema
+	violations []SchemaViolation
+}
+
+func (v *schemaValidator) fail(path, schemaPath, format string, args ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{Path: path, SchemaPath: schemaPath, Message: fmt.Sprintf(format, args...)})
+}
+
+// Returns true if obj validates against schema, without recording anything
+func (v *schemaValidator) matches(obj, schema interface{}, schemaPath string, depth int) bool {
+	sub := &schemaValidator{schema: v.schema}
+	sub.validate(obj, schema, "", schemaPath, depth)
+	return len(sub.violations) == 0
+}
+
+func (v *schemaValidator) validate(obj, schema interface{}, path, schemaPath string, depth int) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(path, schemaPath, "no value is allowed here")
+		}
+		return
+	case map[string]interface{}:
+		v.validateKeywords(obj, s, path, schemaPath, depth)
+	}
+}
+
+func (v *schemaValidator) validateKeywords(obj interface{}, s map[string]interface{}, path, schemaPath string, depth int) {
+	if ref, ok := s["$ref"].(string); ok {
+		if depth >= maxSchemaRefDepth {
+			v.fail(path, schemaPath+"/$ref", "$ref nesting deeper than %d", maxSchemaRefDepth)
+		} else if target, err := resolveSchemaRef(v.schema.root, ref); err != nil {
+			v.fail(path, schemaPath+"/$ref", "%v", err)
+		} else {
+			v.validate(obj, target, path, strings.TrimPrefix(ref, "#"), depth+1)
+		}
+	}
+
+	if t, ok := s["type"]; ok {
+		var types []string
+		switch tv := t.(type) {
+		case string:
+			types = []string{tv}
+		case []interface{}:
+			for _, item := range tv {
+				if name, ok := item.(string); ok {
+					types = append(types, name)
+				}
+			}
+		}
+		matched := false
+		for _, name := range types {
+			if schemaTypeMatches(obj, name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, schemaPath+"/type", "expected %s, got %s", strings.Join(types, " or "), schemaTypeName(obj))
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range enum {
+			if valuesEqual(obj, item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, schemaPath+"/enum", "value %s is not one of %s", diffValueText(obj), diffValueText(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !valuesEqual(obj, c) {
+		v.fail(path, schemaPath+"/const", "value %s is not %s", diffValueText(obj), diffValueText(c))
+	}
+
+	switch value := obj.(type) {
+	case map[string]interface{}:
+		v.validateObject(value, s, path, schemaPath)
+	case []interface{}:
+		v.validateArray(value, s, path, schemaPath)
+	case string:
+		length := len([]rune(value))
+		if n, ok := schemaInt(s, "minLength"); ok && length < n {
+			v.fail(path, schemaPath+"/minLength", "length %d is less than %d", length, n)
+		}
+		if n, ok := schemaInt(s, "maxLength"); ok && length > n {
+			v.fail(path, schemaPath+"/maxLength", "length %d is more than %d", length, n)
+		}
+		if pattern, ok := s["pattern"].(string); ok && !v.schema.regexes[pattern].MatchString(value) {
+			v.fail(path, schemaPath+"/pattern", "%q does not match pattern %q", value, pattern)
+		}
+	default:
+		if f, isNum := toFloat64(obj); isNum {
+			v.validateNumber(f, s, path, schemaPath)
+		}
+	}
+
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		subs, ok := s[keyword].([]interface{})
+		if !ok {
+			continue
+		}
+		kwPath := schemaPath + "/" + keyword
+		if keyword == "allOf" {
+			for i, sub := range subs {
+				v.validate(obj, sub, path, kwPath+"/"+strconv.Itoa(i), depth)
+			}
+			continue
+		}
+		count := 0
+		for i, sub := range subs {
+			if v.matches(obj, sub, kwPath+"/"+strconv.Itoa(i), depth) {
+				count++
+			}
+		}
+		if keyword == "anyOf" && count == 0 {
+			v.fail(path, kwPath, "does not match any of the anyOf schemas")
+		}
+		if keyword == "oneOf" && count != 1 {
+			v.fail(path, kwPath, "matches %d of the oneOf schemas, instead of exactly one", count)
+		}
+	}
+	if not, ok := s["not"]; ok && v.matches(obj, not, schemaPath+"/not", depth) {
+		v.fail(path, schemaPath+"/not", "must not match the \"not\" schema")
+	}
+	if cond, ok := s["if"]; ok {
+		if v.matches(obj, cond, schemaPath+"/if", depth) {
+			if then, ok := s["then"]; ok {
+				v.validate(obj, then, path, schemaPath+"/then", depth)
+			}
+		} else if els, ok := s["else"]; ok {
+			v.validate(obj, els, path, schemaPath+"/else", depth)
+		}
+	}
+}
+
+// Validates the object keywords. Properties are nested values, so the $ref count restarts.
+func (v *schemaValidator) validateObject(obj map[string]interface{}, s map[string]interface{}, path, schemaPath string) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, item := range required {
+			if name, ok := item.(string); ok {
+				if _, found := obj[name]; !found {
+					v.fail(path, schemaPath+"/required", "missing required property %q", name)
+				}
+			}
+		}
+	}
+	if n, ok := schemaInt(s, "minProperties"); ok && len(obj) < n {
+		v.fail(path, schemaPath+"/minProperties", "has %d properties, less than %d", len(obj), n)
+	}
+	if n, ok := schemaInt(s, "maxProperties"); ok && len(obj) > n {
+		v.fail(path, schemaPath+"/maxProperties", "has %d properties, more than %d", len(obj), n)
+	}
+	props, _ := s["properties"].(map[string]interface{})
+	patternProps, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+	patterns := SortObjStringKeys(patternProps)
+	for _, k := range SortObjStringKeys(obj) {
+		childPath := path + "/" + jsonPointerEscape(k)
+		covered := false
+		if sub, ok := props[k]; ok {
+			covered = true
+			v.validate(obj[k], sub, childPath, schemaPath+"/properties/"+jsonPointerEscape(k), 0)
+		}
+		for _, pattern := range patterns {
+			if v.schema.regexes[pattern].MatchString(k) {
+				covered = true
+				v.validate(obj[k], patternProps[pattern], childPath, schemaPath+"/patternProperties/"+jsonPointerEscape(pattern), 0)
+			}
+		}
+		if !covered && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(childPath, schemaPath+"/additionalProperties", "property %q is not allowed", k)
+			} else {
+				v.validate(obj[k], additional, childPath, schemaPath+"/additionalProperties", 0)
+			}
+		}
+	}
+}
+
+// Validates the array keywords. Items are nested values, so the $ref count restarts.
+func (v *schemaValidator) validateArray(list []interface{}, s map[string]interface{}, path, schemaPath string) {
+	if n, ok := schemaInt(s, "minItems"); ok && len(list) < n {
+		v.fail(path, schemaPath+"/minItems", "has %d items, less than %d", len(list), n)
+	}
+	if n, ok := schemaInt(s, "maxItems"); ok && len(list) > n {
+		v.fail(path, schemaPath+"/maxItems", "has %d items, more than %d", len(list), n)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := range list {
+			for j := 0; j < i; j++ {
+				if valuesEqual(list[i], list[j]) {
+					v.fail(path+"/"+strconv.Itoa(i), schemaPath+"/uniqueItems", "duplicates item %d", j)
+					break // Report each duplicate once, against the first of its kind
+				}
+			}
+		}
+	}
+	prefix, _ := s["prefixItems"].([]interface{})
+	for i := 0; i < len(prefix) && i < len(list); i++ {
+		v.validate(list[i], prefix[i], path+"/"+strconv.Itoa(i), schemaPath+"/prefixItems/"+strconv.Itoa(i), 0)
+	}
+	if items, ok := s["items"]; ok {
+		for i := len(prefix); i < len(list); i++ {
+			v.validate(list[i], items, path+"/"+strconv.Itoa(i), schemaPath+"/items", 0)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(f float64, s map[string]interface{}, path, schemaPath string) {
+	if min, ok := toFloat64(s["minimum"]); ok && f < min {
+		v.fail(path, schemaPath+"/minimum", "%v is less than %v", f, min)
+	}
+	if max, ok := toFloat64(s["maximum"]); ok && f > max {
+		v.fail(path, schemaPath+"/maximum", "%v is more than %v", f, max)
+	}
+	if min, ok := toFloat64(s["exclusiveMinimum"]); ok && f <= min {
+		v.fail(path, schemaPath+"/exclusiveMinimum", "%v is not more than %v", f, min)
+	}
+	if max, ok := toFloat64(s["exclusiveMaximum"]); ok && f >= max {
+		v.fail(path, schemaPath+"/exclusiveMaximum", "%v is not less than %v", f, max)
+	}
+	if m, ok := toFloat64(s["multipleOf"]); ok && m > 0 {
+		if q := f / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, schemaPath+"/multipleOf", "%v is not a multiple of %v", f, m)
+		}
+	}
+}
+
+// Returns the subschema within schema document root that a "#..." reference points to.
+// Internal helper function.
+func resolveSchemaRef(root interface{}, ref string) (interface{}, error) {
+	fragment, found := strings.CutPrefix(ref, "#")
+	if !found {
+		return nil, fmt.Errorf("only references within the schema are supported, not %q", ref)
+	}
+	pointer, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("bad $ref %q: %v", ref, err)
+	}
+	target, err := JsonPointerGet(root, pointer)
+	if err != nil {
+		return nil, fmt.Errorf("unresolvable $ref %q", ref)
+	}
+	return target, nil
+}
+
+// Returns keyword's value in schema s as an int, if it is a number. Internal helper function.
+func schemaInt(s map[string]interface{}, keyword string) (int, bool) {
+	f, ok := toFloat64(s[keyword])
+	return int(f), ok
+}
+
+// Returns true if obj is of given JSON Schema type name. Internal helper function.
+func schemaTypeMatches(obj interface{}, name string) bool {
+	switch name {
+	case "integer":
+		f, ok := toFloat64(obj)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := toFloat64(obj)
+		return ok
+	}
+	return schemaTypeName(obj) == name
+}
+
+// Returns the JSON Schema type name of obj. Internal helper function.
+func schemaTypeName(obj interface{}) string {
+	switch obj.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := toFloat64(obj); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", obj)
+}
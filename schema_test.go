@@ -0,0 +1,98 @@
+package utl
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name, schema, obj string
+		want              []string // Violation paths, in order
+	}{
+		{"type ok", `{"type":"object"}`, `{}`, nil},
+		{"type mismatch", `{"type":["string","null"]}`, `1`, []string{""}},
+		{"integer", `{"type":"integer"}`, `1.5`, []string{""}},
+		{"required", `{"required":["a","b"]}`, `{"a":1}`, []string{""}},
+		{"enum", `{"enum":[1,"x"]}`, `"y"`, []string{""}},
+		{"const", `{"const":{"a":[1]}}`, `{"a":[1]}`, nil},
+		{"properties", `{"properties":{"a":{"type":"string"}},"additionalProperties":false}`, `{"a":1,"b":2}`, []string{"/a", "/b"}},
+		{"pattern properties", `{"patternProperties":{"^x-":{"type":"number"}}}`, `{"x-a":"s","y":"s"}`, []string{"/x-a"}},
+		{"string limits", `{"minLength":2,"maxLength":3,"pattern":"^a"}`, `"bcde"`, []string{"", ""}},
+		{"number limits", `{"minimum":1,"exclusiveMaximum":5,"multipleOf":2}`, `5`, []string{"", ""}},
+		{"prefix items and items", `{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`, `[1,2,"x"]`, []string{"/0", "/2"}},
+		{"every duplicate item", `{"uniqueItems":true}`, `[1,1,2,2]`, []string{"/1", "/3"}},
+		{"triple item", `{"uniqueItems":true}`, `[1,1,1]`, []string{"/1", "/2"}},
+		{"anyOf", `{"anyOf":[{"type":"string"},{"minimum":5}]}`, `1`, []string{""}},
+		{"oneOf", `{"oneOf":[{"type":"number"},{"minimum":0}]}`, `1`, []string{""}},
+		{"not", `{"not":{"type":"null"}}`, `null`, []string{""}},
+		{"if then else", `{"if":{"type":"string"},"then":{"minLength":2},"else":{"minimum":0}}`, `-1`, []string{""}},
+		{"$defs ref", `{"$defs":{"s":{"type":"string"}},"properties":{"a":{"$ref":"#/$defs/s"}}}`, `{"a":1}`, []string{"/a"}},
+		{"unresolvable ref", `{"$ref":"#/nowhere"}`, `1`, []string{""}},
+		{"ref loop", `{"$ref":"#"}`, `1`, []string{""}},
+		{"false schema", `{"properties":{"a":false}}`, `{"a":1}`, []string{"/a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := ValidateSchema(mustJsonObj(t, tt.obj), mustJsonObj(t, tt.schema))
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("violations %v, want paths %q", violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaDeepRecursion(t *testing.T) {
+	// Each level is a new instance location, so the $ref hops don't add up
+	schema := mustJsonObj(t, `{"type":"object","properties":{"c":{"$ref":"#"}}}`)
+	obj := strings.Repeat(`{"c":`, 2*maxSchemaRefDepth) + `{}` + strings.Repeat(`}`, 2*maxSchemaRefDepth)
+	violations, err := ValidateSchema(mustJsonObj(t, obj), schema)
+	if err != nil || len(violations) != 0 {
+		t.Errorf("got %v, %v; want no violations", violations, err)
+	}
+}
+
+func TestCompileJsonSchemaPatterns(t *testing.T) {
+	tests := []struct {
+		name, schema string
+		wantErr      bool
+	}{
+		{"const data", `{"const":{"pattern":"("}}`, false},
+		{"enum data", `{"enum":[{"pattern":"("}]}`, false},
+		{"default data", `{"properties":{"a":{"default":{"patternProperties":{"(":{}}}}}}`, false},
+		{"bad pattern", `{"properties":{"a":{"pattern":"("}}}`, true},
+		{"bad pattern property", `{"patternProperties":{"(":{}}}`, true},
+		{"bad pattern in defs", `{"$defs":{"a":{"items":{"pattern":"["}}}}`, true},
+		{"bad pattern by ref", `{"$ref":"#/x/y","x":{"y":{"pattern":"["}}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileJsonSchema(mustJsonObj(t, tt.schema))
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("error %v does not wrap ErrInvalidQuery", err)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaBytesPosition(t *testing.T) {
+	violations, err := ValidateSchemaBytes([]byte("a: 1\nb: x\n"), mustJsonObj(t, `{"properties":{"b":{"type":"number"}}}`))
+	if err != nil || len(violations) != 1 {
+		t.Fatalf("got %v, %v; want one violation", violations, err)
+	}
+	if v := violations[0]; v.Path != "/b" || v.Line != 2 {
+		t.Errorf("got %+v, want /b on line 2", v)
+	}
+}
@@ -0,0 +1,142 @@
+package utl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SearchMode selects how SearchJson compares values against the query
+type SearchMode int
+
+const (
+	SearchSubstring SearchMode = iota // Case insensitive substring, same as SubString (default)
+	SearchExact                       // Whole value must equal the query
+	SearchRegex                       // Query is a regular expression, see regexp/syntax
+	SearchGlob                        // Whole value must match the query as a glob, see Glob
+)
+
+// SearchOption tweaks what SearchJson matches
+type SearchOption func(*searchOptions)
+
+type searchOptions struct {
+	mode       SearchMode
+	keys       bool
+	scalars    bool
+	ignoreCase bool
+}
+
+// Compare values against the query using given mode
+func WithSearchMode(mode SearchMode) SearchOption {
+	return func(o *searchOptions) { o.mode = mode }
+}
+
+// Also match object keys. A key hit reports the path of that key's value.
+func WithSearchKeys() SearchOption {
+	return func(o *searchOptions) { o.keys = true }
+}
+
+// Also match numbers, booleans and nulls, using their JSON text, i.e. 42, true, null
+func WithSearchScalars() SearchOption {
+	return func(o *searchOptions) { o.scalars = true }
+}
+
+// Ignore case in SearchExact, SearchRegex and SearchGlob modes. SearchSubstring always does.
+func WithSearchIgnoreCase() SearchOption {
+	return func(o *searchOptions) { o.ignoreCase = true }
+}
+
+// Recursively searches jsonObject for query, and returns the JSON Pointer path of every hit,
+// in depth-first order with object keys sorted. By default only string values are searched,
+// by case insensitive substring. Objects may also be *OrderedMap, as from WithOrderedMaps(),
+// or yaml.v3's map[interface{}]interface{}. Returns error if query is an invalid regular
+// expression or glob pattern.
+func SearchJson(jsonObject interface{}, query string, opts ...SearchOption) (paths []string, err error) {
+	o := searchOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var match func(string) bool
+	switch o.mode {
+	case SearchExact:
+		match = func(s string) bool { return s == query }
+		if o.ignoreCase {
+			match = func(s string) bool { return strings.EqualFold(s, query) }
+		}
+	case SearchRegex:
+		if o.ignoreCase {
+			query = "(?i)" + query
+		}
+		re, err := regexp.Compile(query)
+		if err != nil {
+			return nil, wrapErr(ErrInvalidQuery, err)
+		}
+		match = re.MatchString
+	case SearchGlob:
+		var globOpts []GlobOption
+		if o.ignoreCase {
+			globOpts = append(globOpts, WithGlobIgnoreCase())
+		}
+		g, err := CompileGlob(query, globOpts...)
+		if err != nil {
+			return nil, wrapErr(ErrInvalidQuery, err)
+		}
+		match = g.Match
+	default:
+		match = func(s string) bool { return SubString(s, query) }
+	}
+	paths = []string{}
+	searchJson(orderedToPlain(jsonObject), "", match, &o, &paths)
+	return paths, nil
+}
+
+// Recursive worker for SearchJson. Internal helper function.
+func searchJson(obj interface{}, path string, match func(string) bool, o *searchOptions, paths *[]string) {
+	switch value := obj.(type) {
+	case map[string]interface{}:
+		for _, k := range SortObjStringKeys(value) {
+			childPath := path + "/" + jsonPointerEscape(k)
+			if o.keys && match(k) {
+				*paths = append(*paths, childPath)
+				if !isContainer(value[k]) {
+					continue // Don't report the same path twice
+				}
+			}
+			searchJson(value[k], childPath, match, o, paths)
+		}
+	case []interface{}:
+		for i, v := range value {
+			searchJson(v, path+"/"+strconv.Itoa(i), match, o, paths)
+		}
+	case string:
+		if match(value) {
+			*paths = append(*paths, path)
+		}
+	default:
+		if o.scalars && match(scalarText(value)) {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// Returns true if v is an object or array. Internal helper function.
+func isContainer(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// Returns the JSON text of a non-string scalar, i.e. 42, 1.5, true or null.
+// Internal helper function.
+func scalarText(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", v))
+}
@@ -3,9 +3,11 @@ package utl
 import (
 	"fmt"
 	"strings"
+
+	"github.com/rivo/uniseg"
 )
 
-// Case insensitive substring check
+// Case insensitive substring check. See FuzzyFind for typo tolerant matching.
 func SubString(large, small string) bool {
 	return strings.Contains(strings.ToLower(large), strings.ToLower(small))
 }
@@ -16,19 +18,17 @@ func LastElem(s, splitter string) string {
 	return split[len(split)-1]
 }
 
-// Return first N chars of string n
+// Return first N chars of string n. A char is a grapheme cluster, so accented letters
+// and emoji sequences are never split. See TruncateWidth to cut by display width instead.
 func FirstN(s string, n int) string {
 	if len(s) <= n {
 		return s
 	}
-	i := 0
-	for j := range s {
-		if i == n {
-			return s[:j]
-		}
-		i++
+	rest, state := s, -1
+	for i := 0; i < n && len(rest) > 0; i++ {
+		_, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
 	}
-	return s
+	return s[:len(s)-len(rest)]
 }
 
 // Return the best printable string value for given x variable
@@ -62,7 +62,7 @@ func ToStr(value interface{}) string {
 }
 
 // ItemInList checks if a given string (arg) is present in a list of strings (argList).
-// Returns true if found, false otherwise.
+// Returns true if found, false otherwise. See ItemInListGlob to match patterns.
 func ItemInList(arg string, argList []string) bool {
 	for _, value := range argList {
 		if value == arg {
@@ -72,6 +72,17 @@ func ItemInList(arg string, argList []string) bool {
 	return false
 }
 
+// ItemInListGlob checks if arg matches any of the glob patterns in patternList, and no
+// negated one, the same as MatchAnyGlob, so {"*.yaml", "!secret*"} takes any YAML file
+// not named secret-something. Malformed patterns are matched literally.
+func ItemInListGlob(arg string, patternList []string, opts ...GlobOption) bool {
+	globs := make([]*Glob, len(patternList))
+	for i, p := range patternList {
+		globs[i] = compileGlobLenient(p, opts...)
+	}
+	return matchAnyGlob(arg, globs)
+}
+
 // Return string of spaces for padded printing. Needed when printing terminal colors.
 // Colorize output uses % sequences that conflict with Printf's own formatting with %
 func PadSpaces(targetWidth, stringWidth int) string {
@@ -84,25 +95,15 @@ func PadSpaces(targetWidth, stringWidth int) string {
 }
 
 // Return value as a string, padded with leading spaces, totalling width size wide. This is
-// needed when printing terminal colors, because they conflict with Printf's own '%' formatting
+// needed when printing terminal colors, because they conflict with Printf's own '%' formatting.
+// Width is in terminal columns, so color codes don't count and wide characters count double.
 func PreSpc(value interface{}, width int) string {
-	str := ToStr(value)
-	padding := width - len(str)
-	if padding > 0 {
-		return fmt.Sprintf("%*s%s", padding, " ", str)
-	} else {
-		return str
-	}
+	return PadLeftWidth(ToStr(value), width)
 }
 
 // Return value as a string, padded with trailing spaces, totalling width size wide. This is
-// needed when printing terminal colors, because they conflict with Printf's own '%' formatting
+// needed when printing terminal colors, because they conflict with Printf's own '%' formatting.
+// Width is in terminal columns, so color codes don't count and wide characters count double.
 func PostSpc(value interface{}, width int) string {
-	str := ToStr(value)
-	padding := width - len(str)
-	if padding > 0 {
-		return fmt.Sprintf("%s%*s", str, padding, " ")
-	} else {
-		return str
-	}
+	return PadRightWidth(ToStr(value), width)
 }
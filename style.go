@@ -0,0 +1,321 @@
+package utl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/color"
+)
+
+// ColorLevel is how many colors the terminal can show
+type ColorLevel int
+
+const (
+	ColorLevelNone ColorLevel = iota
+	ColorLevel16
+	ColorLevel256
+	ColorLevelTrue // 24-bit RGB
+)
+
+// Returns the color level detected from TERM, COLORTERM and the like, or the one given
+// to SetColorLevel
+func GetColorLevel() ColorLevel {
+	switch color.TermColorLevel() {
+	case color.Level16:
+		return ColorLevel16
+	case color.Level256:
+		return ColorLevel256
+	case color.LevelRgb:
+		return ColorLevelTrue
+	}
+	return ColorLevelNone
+}
+
+// Overrides the detected color level. Styles using colors beyond the level are downgraded
+// to the closest color available.
+func SetColorLevel(level ColorLevel) {
+	switch level {
+	case ColorLevel16:
+		color.ForceSetColorLevel(color.Level16)
+	case ColorLevel256:
+		color.ForceSetColorLevel(color.Level256)
+	case ColorLevelTrue:
+		color.ForceSetColorLevel(color.LevelRgb)
+	default:
+		color.ForceSetColorLevel(color.LevelNo)
+	}
+}
+
+// TermColor is a terminal color: one of the 16 basic ANSI colors, one of the 256 xterm
+// colors, or a 24-bit RGB color. The zero value is the terminal's default color.
+type TermColor struct {
+	kind    uint8
+	index   uint8 // For colorBasic and color256
+	r, g, b uint8 // For colorRGB
+}
+
+const (
+	colorDefault = iota
+	colorBasic
+	color256
+	colorRGB
+)
+
+// Returns basic ANSI color index, 0 to 7 for the normal colors, black, red, green,
+// yellow, blue, magenta, cyan and white, and 8 to 15 for their bright versions.
+func Ansi(index uint8) TermColor {
+	return TermColor{kind: colorBasic, index: index % 16}
+}
+
+// Returns xterm 256-color palette entry index
+func Color256(index uint8) TermColor {
+	return TermColor{kind: color256, index: index}
+}
+
+// Returns 24-bit color r, g, b
+func RGB(r, g, b uint8) TermColor {
+	return TermColor{kind: colorRGB, r: r, g: g, b: b}
+}
+
+// Returns the 24-bit color for given "#rrggbb" or "#rgb" hex string, the "#" being
+// optional. Returns error wrapping ErrInvalidColor if it can't be parsed.
+func Hex(hex string) (TermColor, error) {
+	s := strings.TrimPrefix(hex, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 6 || err != nil {
+		return TermColor{}, fmt.Errorf("%w: %q", ErrInvalidColor, hex)
+	}
+	return RGB(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+}
+
+// Same as Hex but panics on error
+func MustHex(hex string) TermColor {
+	c, err := Hex(hex)
+	if err != nil {
+		panic(err.Error())
+	}
+	return c
+}
+
+// Returns the RGB values of the color. The basic colors use xterm's default palette.
+func (c TermColor) RGB() (r, g, b uint8) {
+	switch c.kind {
+	case colorBasic, color256:
+		return xterm256ToRgb(c.index)
+	case colorRGB:
+		return c.r, c.g, c.b
+	}
+	return 0, 0, 0
+}
+
+// Returns the SGR parameters selecting the color, downgraded to given level, or "" for
+// the default color. Internal helper function.
+func (c TermColor) code(level ColorLevel, bg bool) string {
+	kind, index := c.kind, c.index
+	switch {
+	case kind == colorDefault:
+		return ""
+	case kind == colorRGB && level < ColorLevelTrue:
+		kind, index = color256, rgbTo256(c.r, c.g, c.b)
+	}
+	if kind == color256 && level < ColorLevel256 {
+		kind, index = colorBasic, rgbTo16(xterm256ToRgb(index))
+	}
+	base := 38
+	if bg {
+		base = 48
+	}
+	switch kind {
+	case colorBasic:
+		if index < 8 {
+			return strconv.Itoa(base - 8 + int(index))
+		}
+		return strconv.Itoa(base + 52 + int(index) - 8)
+	case color256:
+		return fmt.Sprintf("%d;5;%d", base, index)
+	}
+	return fmt.Sprintf("%d;2;%d;%d;%d", base, c.r, c.g, c.b)
+}
+
+// xterm's default palette for the 16 basic colors
+var ansiPalette = [16][3]uint8{
+	{0x00, 0x00, 0x00}, {0xcd, 0x00, 0x00}, {0x00, 0xcd, 0x00}, {0xcd, 0xcd, 0x00},
+	{0x00, 0x00, 0xee}, {0xcd, 0x00, 0xcd}, {0x00, 0xcd, 0xcd}, {0xe5, 0xe5, 0xe5},
+	{0x7f, 0x7f, 0x7f}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+	{0x5c, 0x5c, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// Intensities of the 6x6x6 color cube in the 256-color palette
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// Returns the RGB values of 256-color palette entry index. Internal helper function.
+func xterm256ToRgb(index uint8) (r, g, b uint8) {
+	switch {
+	case index < 16:
+		p := ansiPalette[index]
+		return p[0], p[1], p[2]
+	case index < 232:
+		i := index - 16
+		return cubeLevels[i/36], cubeLevels[i/6%6], cubeLevels[i%6]
+	}
+	gray := 8 + 10*(index-232)
+	return gray, gray, gray
+}
+
+// Returns the 256-color palette entry closest to r, g, b, from the color cube or the
+// gray ramp. Internal helper function.
+func rgbTo256(r, g, b uint8) uint8 {
+	nearestLevel := func(v uint8) int {
+		best := 0
+		for i, level := range cubeLevels {
+			if absDiff(v, level) < absDiff(v, cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	cube := uint8(16 + 36*nearestLevel(r) + 6*nearestLevel(g) + nearestLevel(b))
+
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayIndex := (avg - 3) / 10 // Ramp runs from 8 to 238 in steps of 10
+	if grayIndex < 0 {
+		grayIndex = 0
+	} else if grayIndex > 23 {
+		grayIndex = 23
+	}
+	gray := uint8(232 + grayIndex)
+
+	if rgbDistance(r, g, b, gray) < rgbDistance(r, g, b, cube) {
+		return gray
+	}
+	return cube
+}
+
+// Returns the basic color closest to r, g, b. Internal helper function.
+func rgbTo16(r, g, b uint8) uint8 {
+	var best uint8
+	for i := uint8(1); i < 16; i++ {
+		if rgbDistance(r, g, b, i) < rgbDistance(r, g, b, best) {
+			best = i
+		}
+	}
+	return best
+}
+
+// Returns the squared distance between r, g, b and 256-color palette entry index
+func rgbDistance(r, g, b, index uint8) int {
+	r2, g2, b2 := xterm256ToRgb(index)
+	dr, dg, db := int(absDiff(r, r2)), int(absDiff(g, g2)), int(absDiff(b, b2))
+	return dr*dr + dg*dg + db*db
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// Style combines foreground and background colors with text attributes. Build one with
+// NewStyle and the chainable setters, then use its Render method like the color variables:
+//
+//	warn := utl.NewStyle().Fg(utl.MustHex("#ff8700")).Bold()
+//	fmt.Println(warn.Render("careful"))
+//
+// Colors beyond the terminal's level, see GetColorLevel, are downgraded when rendering.
+type Style struct {
+	fg, bg TermColor
+	attrs  uint8
+}
+
+const (
+	styleBold uint8 = 1 << iota
+	styleDim
+	styleItalic
+	styleUnderline
+)
+
+// Returns a Style with default colors and no attributes
+func NewStyle() Style {
+	return Style{}
+}
+
+// Returns a copy of the style with given foreground color
+func (s Style) Fg(c TermColor) Style {
+	s.fg = c
+	return s
+}
+
+// Returns a copy of the style with given background color
+func (s Style) Bg(c TermColor) Style {
+	s.bg = c
+	return s
+}
+
+// Returns a copy of the style in bold
+func (s Style) Bold() Style {
+	s.attrs |= styleBold
+	return s
+}
+
+// Returns a copy of the style dimmed
+func (s Style) Dim() Style {
+	s.attrs |= styleDim
+	return s
+}
+
+// Returns a copy of the style in italics, which not all terminals support
+func (s Style) Italic() Style {
+	s.attrs |= styleItalic
+	return s
+}
+
+// Returns a copy of the style underlined
+func (s Style) Underline() Style {
+	s.attrs |= styleUnderline
+	return s
+}
+
+// Renders its arguments, like fmt.Sprint, in the style. Output is plain text when colors
+// are off, see SetColorMode. The method value s.Render is a StyleFunc.
+func (s Style) Render(a ...interface{}) string {
+	if !color.Enable {
+		return fmt.Sprint(a...)
+	}
+	return s.render(GetColorLevel(), a...)
+}
+
+// Renders its arguments in the style, in full color whatever the color mode. Theme styles
+// work this way, as the printers remove or downgrade escape codes to suit each writer, so
+// s.RenderAlways is the StyleFunc to use in a Theme.
+func (s Style) RenderAlways(a ...interface{}) string {
+	return s.render(ColorLevelTrue, a...)
+}
+
+// Renders its arguments in the style, downgraded to given level. Internal helper function.
+func (s Style) render(level ColorLevel, a ...interface{}) string {
+	text := fmt.Sprint(a...)
+	if level == ColorLevelNone || text == "" {
+		return text
+	}
+	var params []string
+	for i, code := range []string{"1", "2", "3", "4"} {
+		if s.attrs&(1<<i) != 0 {
+			params = append(params, code)
+		}
+	}
+	if code := s.fg.code(level, false); code != "" {
+		params = append(params, code)
+	}
+	if code := s.bg.code(level, true); code != "" {
+		params = append(params, code)
+	}
+	if len(params) == 0 {
+		return text
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m" + text + "\x1b[0m"
+}
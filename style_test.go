@@ -0,0 +1,82 @@
+package utl
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHex(t *testing.T) {
+	tests := []struct {
+		hex     string
+		r, g, b uint8
+		wantErr bool
+	}{
+		{"#268bd2", 0x26, 0x8b, 0xd2, false},
+		{"FF8700", 0xff, 0x87, 0x00, false},
+		{"#fa0", 0xff, 0xaa, 0x00, false},
+		{"#12345", 0, 0, 0, true},
+		{"#gggggg", 0, 0, 0, true},
+		{"", 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		c, err := Hex(tt.hex)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidColor) {
+				t.Errorf("Hex(%q) error = %v, want ErrInvalidColor", tt.hex, err)
+			}
+			continue
+		}
+		if r, g, b := c.RGB(); err != nil || r != tt.r || g != tt.g || b != tt.b {
+			t.Errorf("Hex(%q) = %d,%d,%d, %v; want %d,%d,%d", tt.hex, r, g, b, err, tt.r, tt.g, tt.b)
+		}
+	}
+	if !panics(func() { MustHex("nope") }) {
+		t.Errorf("MustHex did not panic")
+	}
+}
+
+func TestStyleRender(t *testing.T) {
+	orange := MustHex("#ff8700")
+	tests := []struct {
+		name  string
+		style Style
+		level ColorLevel
+		want  string
+	}{
+		{"no style", NewStyle(), ColorLevelTrue, "x"},
+		{"level none", NewStyle().Fg(orange).Bold(), ColorLevelNone, "x"},
+		{"truecolor", NewStyle().Fg(orange), ColorLevelTrue, "\x1b[38;2;255;135;0mx\x1b[0m"},
+		{"to 256 colors", NewStyle().Fg(orange), ColorLevel256, "\x1b[38;5;208mx\x1b[0m"},
+		{"to 16 colors", NewStyle().Fg(orange), ColorLevel16, "\x1b[33mx\x1b[0m"},
+		{"256 to 16 colors", NewStyle().Fg(Color256(21)), ColorLevel16, "\x1b[34mx\x1b[0m"},
+		{"basic colors stay", NewStyle().Fg(Ansi(2)).Bg(Ansi(12)), ColorLevel16, "\x1b[32;104mx\x1b[0m"},
+		{"attributes first", NewStyle().Underline().Italic().Dim().Bold().Fg(Ansi(1)), ColorLevel16, "\x1b[1;2;3;4;31mx\x1b[0m"},
+	}
+	for _, tt := range tests {
+		if got := tt.style.render(tt.level, "x"); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := NewStyle().Bold().render(ColorLevelTrue, ""); got != "" {
+		t.Errorf("empty text rendered as %q", got)
+	}
+	if got := NewStyle().Fg(orange).RenderAlways("a", 1); got != "\x1b[38;2;255;135;0ma1\x1b[0m" {
+		t.Errorf("RenderAlways gave %q", got)
+	}
+}
+
+func TestStyleRenderColorMode(t *testing.T) {
+	defer SetColorMode(GetColorMode())
+	defer SetColorLevel(GetColorLevel())
+	style := NewStyle().Fg(MustHex("#ff8700"))
+
+	SetColorMode(ColorNever)
+	if got := style.Render("x"); got != "x" {
+		t.Errorf("ColorNever: got %q", got)
+	}
+	SetColorMode(ColorAlways)
+	SetColorLevel(ColorLevel256)
+	if got := style.Render("x"); got != "\x1b[38;5;208mx\x1b[0m" {
+		t.Errorf("ColorAlways at 256 colors: got %q", got)
+	}
+}
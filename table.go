@@ -0,0 +1,345 @@
+package utl
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Align is the horizontal alignment of a table column
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+	AlignCenter
+)
+
+// TableFormat selects how Table.Render writes a table
+type TableFormat int
+
+const (
+	TableText     TableFormat = iota // Aligned columns for the terminal, colors kept
+	TableCSV                         // RFC 4180 CSV, colors removed
+	TableMarkdown                    // GitHub flavored Markdown table, colors removed
+	TableJSON                        // Array of objects keyed by header, colors removed
+)
+
+// Table lays out rows of cells in aligned columns. Cells may be colored, by Red() and the
+// like, and contain wide characters: widths are measured in terminal columns, ignoring
+// ANSI escape codes. For example:
+//
+//	t := utl.NewTable("NAME", "SIZE")
+//	t.SetAlign(1, utl.AlignRight)
+//	t.AddRow(utl.Gre("data.json"), 1024)
+//	t.Print()
+type Table struct {
+	Headers   []string
+	Rows      [][]string
+	Border    bool   // Draw box borders around and between cells, in TableText output
+	Separator string // Between columns when there's no border, two spaces if empty
+	Ellipsis  string // Ends cells truncated to a column's max width, "…" if empty
+
+	aligns    []Align
+	maxWidths []int
+}
+
+// Returns a new Table with given column headers, which may be none
+func NewTable(headers ...string) *Table {
+	return &Table{Headers: headers}
+}
+
+// Appends a row. Cells are converted to strings with ToStr.
+func (t *Table) AddRow(cells ...interface{}) {
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		row[i] = ToStr(cell)
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// Sets the alignment of given 0-based column, used in TableText and TableMarkdown output
+func (t *Table) SetAlign(column int, align Align) {
+	for len(t.aligns) <= column {
+		t.aligns = append(t.aligns, AlignLeft)
+	}
+	t.aligns[column] = align
+}
+
+// Caps the width of given 0-based column. Wider cells are truncated, ending in Ellipsis,
+// in TableText and TableMarkdown output. Zero removes the cap.
+func (t *Table) SetMaxWidth(column, width int) {
+	for len(t.maxWidths) <= column {
+		t.maxWidths = append(t.maxWidths, 0)
+	}
+	t.maxWidths[column] = width
+}
+
+// Returns the table in TableText format
+func (t *Table) String() string {
+	var sb strings.Builder
+	t.Render(&sb, TableText)
+	return sb.String()
+}
+
+// Prints the table in TableText format to stdout
+func (t *Table) Print() {
+	t.Render(os.Stdout, TableText)
+}
+
+// Writes the table to w in given format. Returns error if any.
+func (t *Table) Render(w io.Writer, format TableFormat) error {
+	switch format {
+	case TableText:
+		_, err := io.WriteString(w, t.text())
+		return wrapErr(ErrFileWrite, err)
+	case TableCSV:
+		cw := csv.NewWriter(w)
+		if len(t.Headers) > 0 {
+			cw.Write(plainCells(t.Headers, t.columns()))
+		}
+		for _, row := range t.Rows {
+			cw.Write(plainCells(row, t.columns()))
+		}
+		cw.Flush()
+		return wrapErr(ErrFileWrite, cw.Error())
+	case TableMarkdown:
+		_, err := io.WriteString(w, t.markdown())
+		return wrapErr(ErrFileWrite, err)
+	case TableJSON:
+		jsonBytes, err := JsonToBytes(t.jsonRows())
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(jsonBytes, '\n'))
+		return wrapErr(ErrFileWrite, err)
+	}
+	return fmt.Errorf("%w: table format %d", ErrUnknownFormat, format)
+}
+
+// Returns the number of columns, that of the widest row or the header
+func (t *Table) columns() int {
+	n := len(t.Headers)
+	for _, row := range t.Rows {
+		if len(row) > n {
+			n = len(row)
+		}
+	}
+	return n
+}
+
+// Returns the alignment of given column
+func (t *Table) align(column int) Align {
+	if column < len(t.aligns) {
+		return t.aligns[column]
+	}
+	return AlignLeft
+}
+
+// Returns row padded out to n cells, with line breaks turned into spaces and cut to its
+// column's max width. Internal helper function.
+func (t *Table) fitCells(row []string, n int) []string {
+	ellipsis := t.Ellipsis
+	if ellipsis == "" {
+		ellipsis = "…"
+	}
+	cells := make([]string, n)
+	for i := range cells {
+		if i < len(row) {
+			cells[i] = strings.NewReplacer("\r\n", " ", "\n", " ").Replace(row[i])
+		}
+		if i < len(t.maxWidths) && t.maxWidths[i] > 0 {
+			cells[i] = TruncateWidth(cells[i], t.maxWidths[i], ellipsis)
+		}
+	}
+	return cells
+}
+
+// Returns all fitted rows, header first if there is one, and the width of each column
+func (t *Table) layout(n int) (rows [][]string, widths []int) {
+	if len(t.Headers) > 0 {
+		rows = append(rows, t.fitCells(t.Headers, n))
+	}
+	for _, row := range t.Rows {
+		rows = append(rows, t.fitCells(row, n))
+	}
+	widths = make([]int, n)
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := DisplayWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	return rows, widths
+}
+
+// Returns cell padded with spaces to width columns as per align
+func padCell(cell string, width int, align Align) string {
+	switch align {
+	case AlignRight:
+		return PadLeftWidth(cell, width)
+	case AlignCenter:
+		return CenterWidth(cell, width)
+	}
+	return PadRightWidth(cell, width)
+}
+
+// Renders the TableText format
+func (t *Table) text() string {
+	n := t.columns()
+	if n == 0 {
+		return ""
+	}
+	rows, widths := t.layout(n)
+	var sb strings.Builder
+	rule := func(left, mid, right string) {
+		sb.WriteString(left)
+		for i, w := range widths {
+			if i > 0 {
+				sb.WriteString(mid)
+			}
+			sb.WriteString(strings.Repeat("─", w+2))
+		}
+		sb.WriteString(right + "\n")
+	}
+	sep := t.Separator
+	if sep == "" {
+		sep = "  "
+	}
+	if t.Border {
+		rule("┌", "┬", "┐")
+	}
+	for r, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if t.Border {
+				line.WriteString("│ " + padCell(cell, widths[i], t.align(i)) + " ")
+				continue
+			}
+			if i > 0 {
+				line.WriteString(sep)
+			}
+			line.WriteString(padCell(cell, widths[i], t.align(i)))
+		}
+		if t.Border {
+			line.WriteString("│")
+			sb.WriteString(line.String() + "\n")
+		} else {
+			sb.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+		}
+		if t.Border && r == 0 && len(t.Headers) > 0 && len(rows) > 1 {
+			rule("├", "┼", "┤")
+		}
+	}
+	if t.Border {
+		rule("└", "┴", "┘")
+	}
+	return sb.String()
+}
+
+// Renders the TableMarkdown format. Markdown requires a header row, so an empty one is
+// used if the table has none.
+func (t *Table) markdown() string {
+	n := t.columns()
+	if n == 0 {
+		return ""
+	}
+	rows, _ := t.layout(n)
+	if len(t.Headers) == 0 {
+		rows = append([][]string{make([]string, n)}, rows...)
+	}
+	widths := make([]int, n)
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = strings.ReplaceAll(StripANSI(cell), "|", `\|`)
+			if w := DisplayWidth(row[i]); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	for i := range widths {
+		if widths[i] < 3 {
+			widths[i] = 3 // Room for the delimiter row's ":-:"
+		}
+	}
+	var sb strings.Builder
+	writeRow := func(cells []string) {
+		sb.WriteString("|")
+		for i, cell := range cells {
+			sb.WriteString(" " + padCell(cell, widths[i], t.align(i)) + " |")
+		}
+		sb.WriteString("\n")
+	}
+	writeRow(rows[0])
+	delims := make([]string, n)
+	for i, w := range widths {
+		switch t.align(i) {
+		case AlignRight:
+			delims[i] = strings.Repeat("-", w-1) + ":"
+		case AlignCenter:
+			delims[i] = ":" + strings.Repeat("-", w-2) + ":"
+		default:
+			delims[i] = strings.Repeat("-", w)
+		}
+	}
+	writeRow(delims)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return sb.String()
+}
+
+// Returns the rows for TableJSON output: objects keyed by header, in column order, or
+// plain arrays if the table has no header. See jsonKeys for the keys.
+func (t *Table) jsonRows() []interface{} {
+	n := t.columns()
+	keys := t.jsonKeys(n)
+	list := make([]interface{}, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		cells := plainCells(row, n)
+		if len(t.Headers) == 0 {
+			list = append(list, cells)
+			continue
+		}
+		obj := NewOrderedMap()
+		for i, cell := range cells {
+			obj.Set(keys[i], cell)
+		}
+		list = append(list, obj)
+	}
+	return list
+}
+
+// Returns the JSON object keys of n columns: the headers without ANSI escape codes, then
+// keys like "column4" for extra cells. A key already taken gets a suffix, as in "name" and
+// "name_2", so no column is lost. Internal helper function.
+func (t *Table) jsonKeys(n int) []string {
+	keys := make([]string, n)
+	taken := map[string]bool{}
+	for i := range keys {
+		base := fmt.Sprintf("column%d", i+1)
+		if i < len(t.Headers) {
+			base = StripANSI(t.Headers[i])
+		}
+		key := base
+		for suffix := 2; taken[key]; suffix++ {
+			key = base + "_" + strconv.Itoa(suffix)
+		}
+		taken[key] = true
+		keys[i] = key
+	}
+	return keys
+}
+
+// Returns given cells without ANSI escape codes, padded out to n. Internal helper.
+func plainCells(row []string, n int) []string {
+	cells := make([]string, n)
+	for i := 0; i < len(row) && i < n; i++ {
+		cells[i] = StripANSI(row[i])
+	}
+	return cells
+}
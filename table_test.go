@@ -0,0 +1,121 @@
+package utl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTableRender(t *testing.T) {
+	const green = "\x1b[32mok\x1b[0m"
+	tests := []struct {
+		name   string
+		build  func() *Table
+		format TableFormat
+		want   string
+	}{
+		{"text", func() *Table {
+			tb := NewTable("NAME", "SIZE")
+			tb.SetAlign(1, AlignRight)
+			tb.AddRow("a.json", 1024)
+			tb.AddRow("bb", 7)
+			return tb
+		}, TableText, "NAME    SIZE\na.json  1024\nbb         7\n"},
+		{"text colored and wide cells", func() *Table {
+			tb := NewTable("名前", "STATE")
+			tb.SetAlign(0, AlignCenter)
+			tb.AddRow("x", green)
+			tb.AddRow("日本語", "fail")
+			return tb
+		}, TableText, " 名前   STATE\n  x     " + green + "\n日本語  fail\n"},
+		{"text border", func() *Table {
+			tb := NewTable("A", "B")
+			tb.Border = true
+			tb.AddRow("1", "22")
+			return tb
+		}, TableText, "┌───┬────┐\n│ A │ B  │\n├───┼────┤\n│ 1 │ 22 │\n└───┴────┘\n"},
+		{"text ragged rows, separator", func() *Table {
+			tb := NewTable()
+			tb.Separator = " | "
+			tb.AddRow("a")
+			tb.AddRow("b", "c")
+			return tb
+		}, TableText, "a |\nb | c\n"},
+		{"text max width", func() *Table {
+			tb := NewTable("K", "V")
+			tb.SetMaxWidth(1, 5)
+			tb.AddRow("a", "abcdefgh")
+			tb.AddRow("b", "日本語です")
+			return tb
+		}, TableText, "K  V\na  abcd…\nb  日本…\n"},
+		{"text max width, own ellipsis, colored", func() *Table {
+			tb := NewTable()
+			tb.Ellipsis = "..."
+			tb.SetMaxWidth(0, 6)
+			tb.AddRow("\x1b[31mabcdefgh\x1b[0m")
+			return tb
+		}, TableText, "\x1b[31mabc...\x1b[0m\n"},
+		{"text line breaks", func() *Table {
+			tb := NewTable()
+			tb.AddRow("a\nb", "c\r\nd")
+			return tb
+		}, TableText, "a b  c d\n"},
+		{"text empty", func() *Table { return NewTable() }, TableText, ""},
+
+		{"csv", func() *Table {
+			tb := NewTable("NAME", "NOTE")
+			tb.AddRow(green, `say "hi", then go`)
+			tb.AddRow("x")
+			return tb
+		}, TableCSV, "NAME,NOTE\nok,\"say \"\"hi\"\", then go\"\nx,\n"},
+
+		{"markdown", func() *Table {
+			tb := NewTable("NAME", "N", "MID")
+			tb.SetAlign(1, AlignRight)
+			tb.SetAlign(2, AlignCenter)
+			tb.AddRow("a|b", 10, green)
+			return tb
+		}, TableMarkdown, "| NAME |   N | MID |\n| ---- | --: | :-: |\n| a\\|b |  10 | ok  |\n"},
+		{"markdown without header", func() *Table {
+			tb := NewTable()
+			tb.SetMaxWidth(0, 4)
+			tb.AddRow("abcdef")
+			return tb
+		}, TableMarkdown, "|      |\n| ---- |\n| abc… |\n"},
+
+		{"json", func() *Table {
+			tb := NewTable("name", "\x1b[1mname\x1b[0m", "name_2", "n")
+			tb.AddRow(green, "b", "c", 1, "extra")
+			return tb
+		}, TableJSON, `[
+  {
+    "name": "ok",
+    "name_2": "b",
+    "name_2_2": "c",
+    "n": "1",
+    "column5": "extra"
+  }
+]
+`},
+		{"json without header", func() *Table {
+			tb := NewTable()
+			tb.AddRow("a", green)
+			return tb
+		}, TableJSON, "[\n  [\n    \"a\",\n    \"ok\"\n  ]\n]\n"},
+		{"json no rows", func() *Table { return NewTable("a") }, TableJSON, "[]\n"},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := tt.build().Render(&sb, tt.format); err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if sb.String() != tt.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, sb.String(), tt.want)
+		}
+	}
+
+	if err := NewTable("a").Render(&strings.Builder{}, TableFormat(99)); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("unknown format error = %v, want ErrUnknownFormat", err)
+	}
+}
@@ -26,7 +26,7 @@ func Int64Abs(x int64) int64 {
 func StringToInt64(s string) (int64, error) {
 	i, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
-		return 0, err
+		return 0, wrapErr(ErrInvalidNumber, err)
 	}
 	return i, nil
 }
@@ -60,7 +60,7 @@ func EpocStringToTime(epocString string) (time.Time, error) {
 func ConvertDateFormat(dateString, srcFormat, dstFormat string) (string, error) {
 	t, err := time.Parse(srcFormat, dateString)
 	if err != nil {
-		return "", err
+		return "", wrapErr(ErrInvalidDate, err)
 	}
 	return t.Format(dstFormat), nil
 }
@@ -69,20 +69,38 @@ func ConvertDateFormat(dateString, srcFormat, dstFormat string) (string, error)
 func DateStringToEpocInt64(dateString, dateFormat string) (int64, error) {
 	t, err := time.Parse(dateFormat, dateString) // First, convert string to Time
 	if err != nil {
-		return 0, err
+		return 0, wrapErr(ErrInvalidDate, err)
 	}
 	return t.Unix(), nil // Finally, convert Time type to Unix epoc seconds
 }
 
-// Print yyyy-mm-dd date for given number of +/- days in future or past
+// Return date for given number of +/- days in future or past.
+// Panics if days is not a valid number.
+//
+// Deprecated: Use GetDateInDaysE, or MustGetDateInDays to keep panicking.
 func GetDateInDays(days string) time.Time {
+	return MustGetDateInDays(days)
+}
+
+// Same as GetDateInDaysE but panics on error
+func MustGetDateInDays(days string) time.Time {
+	t, err := GetDateInDaysE(days)
+	if err != nil {
+		panic(err.Error())
+	}
+	return t
+}
+
+// Return date for given number of +/- days in future or past.
+// Returns error if days is not a valid number.
+func GetDateInDaysE(days string) (time.Time, error) {
 	now := time.Now().Unix()
 	daysInt64, err := StringToInt64(days)
 	if err != nil {
-		panic(err.Error())
+		return time.Time{}, err
 	}
 	now += (daysInt64 * 86400) // 86400 seconds in a day
-	return EpocInt64ToTime(now)
+	return EpocInt64ToTime(now), nil
 }
 
 // Returns true if given year is a leap year. False otherwise.
@@ -92,11 +110,29 @@ func IsLeapYear(year int64) bool {
 
 // Calculate and return number of +/- days from NOW to date given
 // Note: Calculations are all in UTC time. And it takes leap year into account.
+// Panics if date1 is not a valid yyyy-mm-dd date.
+//
+// Deprecated: Use GetDaysSinceOrToE, or MustGetDaysSinceOrTo to keep panicking.
 func GetDaysSinceOrTo(date1 string) int64 {
-	start, err := time.Parse("2006-01-02", date1)
+	return MustGetDaysSinceOrTo(date1)
+}
+
+// Same as GetDaysSinceOrToE but panics on error
+func MustGetDaysSinceOrTo(date1 string) int64 {
+	days, err := GetDaysSinceOrToE(date1)
 	if err != nil {
 		panic(err.Error())
 	}
+	return days
+}
+
+// Calculate and return number of +/- days from NOW to date given, in UTC and taking
+// leap years into account. Returns error if date1 is not a valid yyyy-mm-dd date.
+func GetDaysSinceOrToE(date1 string) (int64, error) {
+	start, err := time.Parse("2006-01-02", date1)
+	if err != nil {
+		return 0, wrapErr(ErrInvalidDate, err)
+	}
 
 	end := time.Now().UTC()
 
@@ -119,7 +155,7 @@ func GetDaysSinceOrTo(date1 string) int64 {
 		}
 	}
 
-	return sign * days
+	return sign * days, nil
 }
 
 // Print number of days, also in years and days
@@ -147,16 +183,34 @@ func PrintDays(days int64) {
 	}
 }
 
-// Return number of days between 2 dates
+// Return number of days between 2 yyyy-mm-dd dates.
+// Panics if either date is invalid.
+//
+// Deprecated: Use GetDaysBetweenE, or MustGetDaysBetween to keep panicking.
 func GetDaysBetween(date1, date2 string) int64 {
-	epoc1, err := DateStringToEpocInt64(date1, "2006-01-02")
+	return MustGetDaysBetween(date1, date2)
+}
+
+// Same as GetDaysBetweenE but panics on error
+func MustGetDaysBetween(date1, date2 string) int64 {
+	days, err := GetDaysBetweenE(date1, date2)
 	if err != nil {
 		panic(err.Error())
 	}
+	return days
+}
+
+// Return number of days between 2 yyyy-mm-dd dates. Returns error if either date is
+// invalid.
+func GetDaysBetweenE(date1, date2 string) (int64, error) {
+	epoc1, err := DateStringToEpocInt64(date1, "2006-01-02")
+	if err != nil {
+		return 0, err
+	}
 	epoc2, err := DateStringToEpocInt64(date2, "2006-01-02")
 	if err != nil {
-		panic(err.Error())
+		return 0, err
 	}
 
-	return (Int64Abs(epoc1-epoc2) / 86400)
+	return (Int64Abs(epoc1-epoc2) / 86400), nil
 }
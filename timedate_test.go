@@ -0,0 +1,59 @@
+package utl
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetDaysBetweenE(t *testing.T) {
+	tests := []struct {
+		date1, date2 string
+		want         int64
+		wantErr      error
+	}{
+		{"2020-01-01", "2021-01-01", 366, nil},
+		{"2021-03-01", "2021-02-01", 28, nil},
+		{"2021-02-30", "2021-03-01", 0, ErrInvalidDate},
+		{"2021-01-01", "tomorrow", 0, ErrInvalidDate},
+	}
+	for _, tt := range tests {
+		got, err := GetDaysBetweenE(tt.date1, tt.date2)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("GetDaysBetweenE(%q, %q) = %d, %v, want %d, %v", tt.date1, tt.date2, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGetDaysSinceOrToE(t *testing.T) {
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	if days, err := GetDaysSinceOrToE(tomorrow); err != nil || days != 1 {
+		t.Errorf("GetDaysSinceOrToE(%q) = %d, %v, want 1", tomorrow, days, err)
+	}
+	if _, err := GetDaysSinceOrToE("2021-13-01"); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("error = %v, want ErrInvalidDate", err)
+	}
+}
+
+func TestGetDateInDaysE(t *testing.T) {
+	got, err := GetDateInDaysE("-2")
+	if want := time.Now().AddDate(0, 0, -2); err != nil || got.Sub(want).Abs() > time.Minute {
+		t.Errorf("GetDateInDaysE(-2) = %v, %v, want about %v", got, err, want)
+	}
+	if _, err := GetDateInDaysE("two"); !errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("error = %v, want ErrInvalidNumber", err)
+	}
+}
+
+func TestMustDateHelpersPanic(t *testing.T) {
+	for name, fn := range map[string]func(){
+		"MustGetDateInDays":    func() { MustGetDateInDays("x") },
+		"MustGetDaysSinceOrTo": func() { MustGetDaysSinceOrTo("x") },
+		"MustGetDaysBetween":   func() { MustGetDaysBetween("x", "y") },
+		"GetDaysBetween":       func() { GetDaysBetween("x", "y") },
+	} {
+		if !panics(fn) {
+			t.Errorf("%s did not panic", name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package utl
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// The functions below measure strings in terminal columns. They segment text into
+// grapheme clusters, so a letter with combining accents, a flag, or an emoji ZWJ sequence
+// counts as the single character it is displayed as, East Asian wide characters take two
+// columns, and ANSI escape codes, like those from Red(), take none.
+
+// CSI sequences, like SGR colors, and OSC sequences, like hyperlinks
+var ansiRegex = regexp.MustCompile("\x1b\\[[0-?]*[ -/]*[@-~]|\x1b\\][^\x07\x1b]*(?:\x07|\x1b\\\\)")
+
+// Returns given string with ANSI escape codes removed
+func StripANSI(s string) string {
+	return ansiRegex.ReplaceAllString(s, "")
+}
+
+// Returns the number of terminal columns given string takes up
+func DisplayWidth(s string) int {
+	return uniseg.StringWidth(StripANSI(s))
+}
+
+// Returns the length of the ANSI escape sequence at the start of s, or 0 if there's none.
+// Internal helper function.
+func ansiPrefixLen(s string) int {
+	if loc := ansiRegex.FindStringIndex(s); loc != nil && loc[0] == 0 {
+		return loc[1]
+	}
+	return 0
+}
+
+// Returns s cut down to at most width columns, ending in tail, i.e. "…", if anything
+// was cut, or as much of tail as fits if width is smaller still. Grapheme clusters are
+// never split, and ANSI escape codes are kept, with a reset added after tail if there
+// were any, so an open color doesn't bleed into what follows.
+func TruncateWidth(s string, width int, tail string) string {
+	width = max(width, 0)
+	if DisplayWidth(s) <= width {
+		return s
+	}
+	if DisplayWidth(tail) > width {
+		tail = TruncateWidth(tail, width, "") // Not even tail fits, so it gets cut too
+	}
+	width -= DisplayWidth(tail)
+	var sb strings.Builder
+	sawEscape := false
+	used, state := 0, -1
+	for len(s) > 0 {
+		if n := ansiPrefixLen(s); n > 0 {
+			sb.WriteString(s[:n])
+			s = s[n:]
+			sawEscape = true
+			continue
+		}
+		var cluster string
+		var w int
+		cluster, s, w, state = uniseg.FirstGraphemeClusterInString(s, state)
+		if used+w > width {
+			break
+		}
+		sb.WriteString(cluster)
+		used += w
+	}
+	sb.WriteString(tail)
+	if sawEscape {
+		sb.WriteString("\x1b[0m")
+	}
+	return sb.String()
+}
+
+// Returns s padded with leading spaces to width columns, so it lines up on the right.
+// Returns s as is if it's already as wide.
+func PadLeftWidth(s string, width int) string {
+	return PadSpaces(width, DisplayWidth(s)) + s
+}
+
+// Returns s padded with trailing spaces to width columns, so it lines up on the left.
+// Returns s as is if it's already as wide.
+func PadRightWidth(s string, width int) string {
+	return s + PadSpaces(width, DisplayWidth(s))
+}
+
+// Returns s centered in width columns, with any odd space on the right. Returns s as is
+// if it's already as wide.
+func CenterWidth(s string, width int) string {
+	gap := width - DisplayWidth(s)
+	if gap <= 0 {
+		return s
+	}
+	return PadSpaces(gap/2, 0) + s + PadSpaces(gap-gap/2, 0)
+}
@@ -0,0 +1,69 @@
+package utl
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{Red("hello"), 5},
+		{"日本", 4},
+		{"é", 1},                         // Combining accent
+		{"🇨🇦", 2},                         // Flag
+		{"👩‍💻", 2},                        // ZWJ sequence
+		{"\x1b]8;;x\x07a\x1b]8;;\x07", 1}, // Hyperlink
+	}
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.s); got != tt.want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		tail  string
+		want  string
+	}{
+		{"hello", 5, "…", "hello"},
+		{"hello world", 8, "…", "hello w…"},
+		{"hello world", 8, "...", "hello..."},
+		{"hello world", 3, "...", "..."},
+		{"hello world", 2, "...", ".."},
+		{"hello world", 0, "...", ""},
+		{"hello world", -1, "...", ""},
+		{"日本語", 5, "…", "日本…"},
+		{"日本語", 4, "…", "日…"},
+		{"\x1b[31mhello world\x1b[0m", 6, "…", "\x1b[31mhello…\x1b[0m"},
+	}
+	for _, tt := range tests {
+		got := TruncateWidth(tt.s, tt.width, tt.tail)
+		if got != tt.want {
+			t.Errorf("TruncateWidth(%q, %d, %q) = %q, want %q", tt.s, tt.width, tt.tail, got, tt.want)
+		}
+		if w := DisplayWidth(got); w > tt.width && tt.width >= 0 {
+			t.Errorf("TruncateWidth(%q, %d, %q) is %d wide", tt.s, tt.width, tt.tail, w)
+		}
+	}
+}
+
+func TestPadWidth(t *testing.T) {
+	tests := []struct {
+		name, got, want string
+	}{
+		{"left", PadLeftWidth("日", 4), "  日"},
+		{"right", PadRightWidth(Red("ab"), 4), Red("ab") + "  "},
+		{"center", CenterWidth("ab", 5), " ab  "},
+		{"too wide", PadRightWidth("abc", 2), "abc"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
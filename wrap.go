@@ -0,0 +1,173 @@
+package utl
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/term"
+)
+
+// WrapOption tweaks how Wrap lays out its lines
+type WrapOption func(*wrapOptions)
+
+type wrapOptions struct {
+	prefix string
+	indent int
+}
+
+// Start every line with prefix, i.e. "# " or "> ". Its width counts toward the total.
+func WithPrefix(prefix string) WrapOption {
+	return func(o *wrapOptions) { o.prefix = prefix }
+}
+
+// Indent every line but the first by n spaces, after any prefix. Handy for a description
+// printed next to a column padded with PostSpc, where n is the column's width.
+func WithHangingIndent(n int) WrapOption {
+	return func(o *wrapOptions) { o.indent = n }
+}
+
+// Returns the width of the terminal stdout is on, else the COLUMNS environment variable,
+// else 80.
+func TerminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
+
+// Returns text reflowed into lines at most width columns wide, see DisplayWidth, breaking
+// at spaces. Words too long for a line are broken between grapheme clusters. Line breaks
+// already in text start a new paragraph. A color span that crosses a break is closed at the
+// end of the line and reopened on the next, so prefixes and indents stay uncolored. A width
+// of zero or less means TerminalWidth().
+func Wrap(text string, width int, opts ...WrapOption) string {
+	var o wrapOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if width <= 0 {
+		width = TerminalWidth()
+	}
+	trailing := strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+
+	w := &wrapper{o: o, width: width}
+	for _, paragraph := range strings.Split(text, "\n") {
+		w.startLine()
+		for _, word := range strings.Fields(paragraph) {
+			w.addWord(word)
+		}
+		w.endLine()
+	}
+	result := strings.Join(w.lines, "\n")
+	if trailing {
+		result += "\n"
+	}
+	return result
+}
+
+// wrapper accumulates the lines of a Wrap call
+type wrapper struct {
+	o       wrapOptions
+	width   int
+	lines   []string
+	current strings.Builder
+	used    int      // Columns taken on the current line, prefix and indent excluded
+	empty   bool     // Nothing on the current line yet
+	active  []string // SGR sequences in effect, to reopen on the next line
+}
+
+// Returns the columns available for text on the current line
+func (w *wrapper) avail() int {
+	n := w.width - DisplayWidth(w.o.prefix)
+	if len(w.lines) > 0 {
+		n -= w.o.indent
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func (w *wrapper) startLine() {
+	w.current.Reset()
+	w.current.WriteString(strings.Join(w.active, ""))
+	w.used = 0
+	w.empty = true
+}
+
+func (w *wrapper) endLine() {
+	lead := w.o.prefix
+	if len(w.lines) > 0 {
+		lead += strings.Repeat(" ", w.o.indent)
+	}
+	if w.empty {
+		w.lines = append(w.lines, strings.TrimRight(lead, " "))
+		return
+	}
+	line := w.current.String()
+	if len(w.active) > 0 {
+		line += "\x1b[0m"
+	}
+	w.lines = append(w.lines, lead+line)
+}
+
+// Adds word, which has no spaces, to the current line or a new one
+func (w *wrapper) addWord(word string) {
+	width := DisplayWidth(word)
+	if !w.empty {
+		if w.used+1+width <= w.avail() {
+			w.current.WriteString(" ")
+			w.used++
+			w.write(word, width)
+			return
+		}
+		w.endLine()
+		w.startLine()
+	}
+	if width <= w.avail() {
+		w.write(word, width)
+		return
+	}
+	// Too long for any line, so break it wherever it hits the edge
+	state := -1
+	for len(word) > 0 {
+		if n := ansiPrefixLen(word); n > 0 {
+			w.write(word[:n], 0)
+			word = word[n:]
+			continue
+		}
+		var cluster string
+		var cw int
+		cluster, word, cw, state = uniseg.FirstGraphemeClusterInString(word, state)
+		if !w.empty && w.used+cw > w.avail() {
+			w.endLine()
+			w.startLine()
+		}
+		w.write(cluster, cw)
+	}
+}
+
+// Appends s, width columns wide, to the current line, tracking the colors it sets
+func (w *wrapper) write(s string, width int) {
+	w.current.WriteString(s)
+	w.used += width
+	if width > 0 {
+		w.empty = false
+	}
+	for _, esc := range ansiRegex.FindAllString(s, -1) {
+		if !strings.HasPrefix(esc, "\x1b[") || !strings.HasSuffix(esc, "m") {
+			continue // Not SGR
+		}
+		if params := esc[2 : len(esc)-1]; params == "" || params == "0" {
+			w.active = nil
+		} else {
+			w.active = append(w.active, esc)
+		}
+	}
+}
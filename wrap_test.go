@@ -0,0 +1,60 @@
+package utl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	const red, reset = "\x1b[31m", "\x1b[0m"
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		opts  []WrapOption
+		want  string
+	}{
+		{"fits", "one two", 10, nil, "one two"},
+		{"breaks at spaces", "the quick brown fox jumps", 10, nil, "the quick\nbrown fox\njumps"},
+		{"extra spaces collapse", "  a   b  ", 10, nil, "a b"},
+		{"exact width", "abcde fghij", 5, nil, "abcde\nfghij"},
+		{"paragraphs kept", "a b\n\nc d\n", 10, nil, "a b\n\nc d\n"},
+		{"long word broken", "ab abcdefghij c", 4, nil, "ab\nabcd\nefgh\nij c"},
+		{"long word at start", "abcdefg", 3, nil, "abc\ndef\ng"},
+		{"wide runes", "日本語 テキスト", 6, nil, "日本語\nテキス\nト"},
+		{"wide rune not split", "a日本", 2, nil, "a\n日\n本"},
+		{"grapheme clusters kept", "ééé", 2, nil, "éé\né"},
+		{"ansi codes take no room", red + "aaa" + reset + " bbb", 7, nil, red + "aaa" + reset + " bbb"},
+		{"color reopened across break", red + "aaa bbb" + reset + " c", 5, nil,
+			red + "aaa" + reset + "\n" + red + "bbb" + reset + " c"},
+		{"color in long word", red + "abcdef" + reset, 3, nil, red + "abc" + reset + "\n" + red + "def" + reset},
+		{"prefix", "aa bb cc", 7, []WrapOption{WithPrefix("# ")}, "# aa bb\n# cc"},
+		{"prefix on empty line", "a\n\nb", 7, []WrapOption{WithPrefix("> ")}, "> a\n>\n> b"},
+		{"hanging indent", "aa bb cc dd", 6, []WrapOption{WithHangingIndent(2)}, "aa bb\n  cc\n  dd"},
+		{"prefix and indent", "aa bb cc", 6, []WrapOption{WithPrefix("#"), WithHangingIndent(1)}, "#aa bb\n# cc"},
+		{"colored word kept off indent", red + "aa bb" + reset, 3, []WrapOption{WithHangingIndent(1)},
+			red + "aa" + reset + "\n " + red + "bb" + reset},
+		{"no room left", "ab", 1, []WrapOption{WithPrefix(">>")}, ">>a\n>>b"},
+	}
+	for _, tt := range tests {
+		got := Wrap(tt.text, tt.width, tt.opts...)
+		if got != tt.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+		for i, line := range strings.Split(got, "\n") {
+			if w := DisplayWidth(line); w > max(tt.width, 3) {
+				t.Errorf("%s: line %d is %d columns wide", tt.name, i+1, w)
+			}
+		}
+	}
+}
+
+func TestWrapTerminalWidth(t *testing.T) {
+	t.Setenv("COLUMNS", "12")
+	if got := TerminalWidth(); got != 12 {
+		t.Skipf("stdout is a terminal %d columns wide", got)
+	}
+	if got, want := Wrap("aaaa bbbb cccc", 0), "aaaa bbbb\ncccc"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -2,7 +2,9 @@ package utl
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -12,20 +14,37 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// Trys to read, load, and decode given filePath as some YAML object.
-// Returns YAML object and error if any.
-func LoadFileYaml(filePath string) (yamlObject interface{}, err error) {
+// Trys to read, load, and decode given filePath as some YAML object. Only the first
+// document of a multi-document file is decoded, see LoadFileYamlAll for the rest.
+// Accepts WithOrderedMaps() and WithExpandAliases(). Returns YAML object and error if any.
+func LoadFileYaml(filePath string, opts ...LoadOption) (yamlObject interface{}, err error) {
 	fileContent, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, err
+		return nil, readErr(err)
+	}
+	if o := newLoadOptions(opts); o.ordered || o.expand {
+		yamlObject, err = bytesToYamlWithOptions(fileContent, o)
+		return yamlObject, withParseSource(err, filePath, fileContent)
 	}
 	err = yaml.Unmarshal(fileContent, &yamlObject)
 	if err != nil {
-		return nil, err
+		return nil, withParseSource(yamlParseError(err, fileContent), filePath, nil)
 	}
 	return yamlObject, nil
 }
 
+// Reads, load, and decode every document in given filePath, a YAML stream with documents
+// separated by "---". Accepts WithOrderedMaps() and WithExpandAliases().
+// Returns YAML objects and error if any.
+func LoadFileYamlAll(filePath string, opts ...LoadOption) (yamlObjects []interface{}, err error) {
+	fileContent, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, readErr(err)
+	}
+	yamlObjects, err = BytesToYamlObjects(fileContent, opts...)
+	return yamlObjects, withParseSource(err, filePath, nil)
+}
+
 // Tries to read, load, and recode given filePath as some YAML object as byte slice.
 // Returns YAML object as byte slice. and error if any.
 func LoadFileYamlBytes(filePath string) (yamlBytes []byte, err error) {
@@ -33,54 +52,155 @@ func LoadFileYamlBytes(filePath string) (yamlBytes []byte, err error) {
 	// Can also JSON file into byte slice!
 	yamlBytes, err = os.ReadFile(filePath)
 	if err != nil {
-		return nil, err
+		return nil, readErr(err)
 	}
 	// Check YAML formatting compliancy using "github.com/goccy/go-yaml"
 	// which provides errors with line numbers
 	var yamlObject interface{}
 	err = goyaml.Unmarshal(yamlBytes, &yamlObject)
 	if err != nil {
-		return nil, err
+		return nil, withParseSource(yamlParseError(err, yamlBytes), filePath, nil)
 	}
 	return yamlBytes, nil // We only care about returning the byte slice
 }
 
-// Save given YAML object to given filePath
+// Save given YAML object to given filePath, atomically. Panics on error.
+//
+// Deprecated: Use SaveFileYamlE, or MustSaveFileYaml to keep panicking.
 func SaveFileYaml(yamlObject interface{}, filePath string) {
+	MustSaveFileYaml(yamlObject, filePath)
+}
+
+// Same as SaveFileYamlE but panics on error
+func MustSaveFileYaml(yamlObject interface{}, filePath string, opts ...SaveOption) {
+	if err := SaveFileYamlE(yamlObject, filePath, opts...); err != nil {
+		panic(err.Error())
+	}
+}
+
+// Save given YAML object to given filePath, atomically. Returns error if any.
+func SaveFileYamlE(yamlObject interface{}, filePath string, opts ...SaveOption) (err error) {
+	defer recoverMarshalPanic(&err)
 	yamlData, err := yaml.Marshal(&yamlObject)
 	if err != nil {
-		panic(err.Error())
+		return wrapErr(ErrMarshal, err)
 	}
-	err = os.WriteFile(filePath, yamlData, 0600)
+	return WriteFileAtomic(filePath, yamlData, 0600, opts...)
+}
+
+// Save given YAML objects to given filePath as a multi-document stream, atomically.
+// Returns error if any.
+func SaveFileYamlAll(yamlObjects []interface{}, filePath string, opts ...SaveOption) error {
+	yamlData, err := YamlToBytesAll(yamlObjects)
 	if err != nil {
-		panic(err.Error())
+		return err
+	}
+	return WriteFileAtomic(filePath, yamlData, 0600, opts...)
+}
+
+// Convert byte slice to YAML interface objects, one per document in the stream.
+// Accepts WithOrderedMaps() and WithExpandAliases(). Returns YAML objects and error if any.
+func BytesToYamlObjects(yamlBytes []byte, opts ...LoadOption) (yamlObjects []interface{}, err error) {
+	o := newLoadOptions(opts)
+	decoder := yaml.NewDecoder(bytes.NewReader(yamlBytes))
+	yamlObjects = []interface{}{}
+	for {
+		var node yaml.Node
+		if err = decoder.Decode(&node); err == io.EOF {
+			return yamlObjects, nil
+		} else if err != nil {
+			return nil, yamlParseError(err, yamlBytes)
+		}
+		yamlObject, err := decodeYamlNode(&node, o)
+		if err != nil {
+			return nil, withParseSource(err, "", yamlBytes)
+		}
+		yamlObjects = append(yamlObjects, yamlObject)
 	}
 }
 
-// Convert byte slice to YAML interface object
-func BytesToYamlObject(yamlBytes []byte) (yamlObject interface{}, err error) {
+// Convert byte slice to YAML interface object. Accepts WithOrderedMaps() and
+// WithExpandAliases().
+func BytesToYamlObject(yamlBytes []byte, opts ...LoadOption) (yamlObject interface{}, err error) {
+	if o := newLoadOptions(opts); o.ordered || o.expand {
+		yamlObject, err = bytesToYamlWithOptions(yamlBytes, o)
+		return yamlObject, withParseSource(err, "", yamlBytes)
+	}
 	buffer := bytes.NewBuffer(yamlBytes)
 	decoder := yaml.NewDecoder(buffer)
 	err = decoder.Decode(&yamlObject)
 	if err != nil {
-		return nil, err
+		return nil, yamlParseError(err, yamlBytes)
+	}
+	return yamlObject, nil
+}
+
+// Decodes the first YAML document in yamlBytes via its node tree, as required by the
+// ordered and expand options. Internal helper function.
+func bytesToYamlWithOptions(yamlBytes []byte, o loadOptions) (yamlObject interface{}, err error) {
+	var node yaml.Node
+	if err = yaml.Unmarshal(yamlBytes, &node); err != nil {
+		return nil, yamlParseError(err, yamlBytes)
+	}
+	if node.Kind == 0 {
+		return nil, wrapErr(ErrUnmarshal, io.EOF) // Same as BytesToYamlObject on empty input
+	}
+	return decodeYamlNode(&node, o)
+}
+
+// Decodes a parsed YAML document according to given options. Internal helper function.
+func decodeYamlNode(node *yaml.Node, o loadOptions) (yamlObject interface{}, err error) {
+	if o.expand {
+		if node, err = ExpandYamlNode(node, o.aliasLimit); err != nil {
+			return nil, err
+		}
+	}
+	if o.ordered {
+		yamlObject, err = yamlNodeToOrdered(node)
+	} else {
+		err = node.Decode(&yamlObject)
+	}
+	if err != nil {
+		return nil, yamlParseError(err, nil) // Callers add the source
 	}
 	return yamlObject, nil
 }
 
 // Convert YAML interface object to byte slice, with option indent spacing
 func YamlToBytesIndent(yamlObject interface{}, indent int) (yamlBytes []byte, err error) {
+	defer recoverMarshalPanic(&err)
 	buffer := &bytes.Buffer{}
 	encoder := yaml.NewEncoder(buffer)
 	encoder.SetIndent(indent)
 	err = encoder.Encode(yamlObject)
 	if err != nil {
-		return nil, err
+		return nil, wrapErr(ErrMarshal, err)
 	}
 	yamlBytes = buffer.Bytes()
 	return yamlBytes, nil
 }
 
+// Convert YAML interface objects to a multi-document byte slice, with documents separated
+// by "---" and default 2 space indent. No objects give an empty byte slice.
+func YamlToBytesAll(yamlObjects []interface{}) (yamlBytes []byte, err error) {
+	if len(yamlObjects) == 0 {
+		return []byte{}, nil // yaml.v3's encoder fails to close an empty stream
+	}
+	defer recoverMarshalPanic(&err)
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+	for _, yamlObject := range yamlObjects {
+		if err = encoder.Encode(yamlObject); err != nil {
+			return nil, wrapErr(ErrMarshal, err)
+		}
+	}
+	if err = encoder.Close(); err != nil {
+		return nil, wrapErr(ErrMarshal, err)
+	}
+	return buffer.Bytes(), nil
+}
+
 // With default 2 space indent
 func YamlToBytes(yamlObject interface{}) (yamlBytes []byte, err error) {
 	indent := 2
@@ -88,6 +208,14 @@ func YamlToBytes(yamlObject interface{}) (yamlBytes []byte, err error) {
 	return yamlBytes, err
 }
 
+// Turns a panic from yaml.v3, which panics on values it can't marshal, like channels,
+// into an error wrapping ErrMarshal. Use with defer. Internal helper function.
+func recoverMarshalPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%w: %v", ErrMarshal, r)
+	}
+}
+
 // Print YAML object
 func PrintYaml(yamlObject interface{}) {
 	yamlBytes, err := YamlToBytes(yamlObject)
@@ -98,88 +226,119 @@ func PrintYaml(yamlObject interface{}) {
 	fmt.Println(string(yamlBytes))
 }
 
-// Colorize given token. Internal helper function.
-func colorizeString(tk *token.Token, src string) string {
-	str := Whi(src)
+// Colorize given token with given theme. Internal helper function.
+func colorizeString(tk *token.Token, src string, theme *Theme) string {
+	str := theme.Plain(src)
 	switch tk.Type {
 	case token.MappingKeyType:
-		str = Blu(src)
+		str = theme.Key(src)
 	case token.StringType, token.SingleQuoteType, token.DoubleQuoteType:
 		prev := tk.PreviousType()
 		next := tk.NextType()
 		if next == token.MappingValueType {
-			str = Blu(src)
+			str = theme.Key(src)
 		} else if prev == token.AnchorType || prev == token.AliasType {
-			str = Yel(src)
+			str = theme.Anchor(src)
 		} else {
-			str = Gre(src)
+			str = theme.String(src)
 		}
-	case token.IntegerType, token.FloatType, token.BoolType:
-		str = Mag(src)
-	case token.AnchorType, token.AliasType:
-		str = Yel(src)
+	case token.IntegerType, token.FloatType, token.InfinityType, token.NanType,
+		token.BinaryIntegerType, token.OctetIntegerType, token.HexIntegerType:
+		str = theme.Number(src)
+	case token.BoolType:
+		str = theme.Bool(src)
+	case token.NullType:
+		str = theme.Null(src)
+	case token.AnchorType, token.AliasType, token.TagType:
+		str = theme.Anchor(src)
 	case token.CommentType:
-		str = Whi(src)
+		str = theme.Comment(src)
+	case token.DocumentHeaderType, token.DocumentEndType:
+		str = theme.Document(src)
 	}
 	return str
 }
 
-// Print YAML object (that don't usually include comments) in color
-func PrintYamlColor(yamlObject interface{}) {
+// Print YAML object (that don't usually include comments) in color.
+// See PrintYamlBytesColor for opts. Returns marshal or write error, if any.
+func PrintYamlColor(yamlObject interface{}, opts ...PrintOption) error {
 	yamlBytes, err := YamlToBytes(yamlObject)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return err
 	}
-	PrintYamlBytesColor(yamlBytes)
+	return PrintYamlBytesColor(yamlBytes, opts...)
 }
 
-// Print YAML bytes in color, includes comments
-// Also prints JSON byte slice in color, but use json.go:PrintJsonBytesColor() alias instead.
-// Caller must ensure yamlBytes is proper YAML/JSON
-func PrintYamlBytesColor(yamlBytes []byte) {
+// Print YAML bytes in color, includes comments. Options WithWriter(), WithTheme(),
+// WithLineNumbers() and WithHighlight() select another writer and theme, and turn on line
+// numbers and line highlighting. Caller must ensure yamlBytes is proper YAML. For JSON use
+// PrintJsonBytesColor, which colors by JSON's own rules. Returns write error, if any.
+func PrintYamlBytesColor(yamlBytes []byte, opts ...PrintOption) error {
 	tokens := lexer.Tokenize(string(yamlBytes))
 	if len(tokens) == 0 {
-		return
+		return nil
 	}
-	printOut := []string{}
-	//lineNumber := tokens[0].Position.Line
+	o := newPrintOptions(opts)
+	lines := []string{""}
 	for _, tk := range tokens {
-		lines := strings.Split(tk.Origin, "\n")
-		header := ""
-		// if allowLineNumber {
-		// 	header = fmt.Sprintf("%2d  ", lineNumber)
-		// }
-		if len(lines) == 1 {
-			line := colorizeString(tk, lines[0])
-			if len(printOut) == 0 {
-				printOut = append(printOut, header+line)
-				//lineNumber++
-			} else {
-				text := printOut[len(printOut)-1]
-				printOut[len(printOut)-1] = text + line
+		// A token's Origin includes the whitespace and line breaks that lead up to it
+		for idx, src := range strings.Split(tk.Origin, "\n") {
+			if idx > 0 {
+				lines = append(lines, "")
 			}
-		} else {
-			header := ""
-			for idx, src := range lines {
-				// if allowLineNumber {
-				// 	header = fmt.Sprintf("%2d  ", lineNumber)
-				// }
-				line := colorizeString(tk, src)
-				if idx == 0 {
-					if len(printOut) == 0 {
-						printOut = append(printOut, header+line)
-						//lineNumber++
-					} else {
-						text := printOut[len(printOut)-1]
-						printOut[len(printOut)-1] = text + line
-					}
-				} else {
-					printOut = append(printOut, fmt.Sprintf("%s%s", header, line))
-					//lineNumber++
-				}
+			if src != "" {
+				lines[len(lines)-1] += colorizeString(tk, src, o.theme)
 			}
 		}
 	}
-	fmt.Println(strings.Join(printOut, "\n"))
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1] // Input's final line break
+	}
+	return writeColorLines(lines, o)
+}
+
+// Convert byte slice to a value of type T. Option WithStrict() makes fields absent from T
+// an error wrapping ErrUnknownField, naming the offending field and its line.
+// Returns decoded value and error if any.
+func BytesToYamlObjectAs[T any](yamlBytes []byte, opts ...LoadOption) (value T, err error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(yamlBytes))
+	decoder.KnownFields(newLoadOptions(opts).strict)
+	if err = decoder.Decode(&value); err != nil && err != io.EOF { // Empty input is no error
+		return value, yamlDecodeError(err, yamlBytes, &value)
+	}
+	return value, nil
+}
+
+// Reads, load, and decode given filePath as a YAML file into a value of type T.
+// See BytesToYamlObjectAs for options. Returns decoded value and error if any.
+func LoadFileYamlAs[T any](filePath string, opts ...LoadOption) (value T, err error) {
+	fileContent, err := os.ReadFile(filePath)
+	if err != nil {
+		return value, readErr(err)
+	}
+	value, err = BytesToYamlObjectAs[T](fileContent, opts...)
+	return value, withParseSource(err, filePath, nil)
+}
+
+// Reads, load, and decode given filePath as a gzipped YAML file into a value of type T.
+// See BytesToYamlObjectAs for options. Returns decoded value and error if any.
+func LoadFileYamlGzipAs[T any](filePath string, opts ...LoadOption) (value T, err error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return value, readErr(err)
+	}
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return value, readErr(err)
+	}
+	defer gzipReader.Close()
+
+	fileContent, err := io.ReadAll(gzipReader)
+	if err != nil {
+		return value, readErr(err)
+	}
+	value, err = BytesToYamlObjectAs[T](fileContent, opts...)
+	return value, withParseSource(err, filePath, nil)
 }
@@ -0,0 +1,116 @@
+package utl
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveFileYamlE(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "x.yaml")
+	obj := map[string]interface{}{"a": []interface{}{1, "x"}}
+	if err := SaveFileYamlE(obj, path); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if got, err := LoadFileYaml(path); err != nil || !reflect.DeepEqual(got, obj) {
+		t.Errorf("loaded %v, %v; want %v", got, err, obj)
+	}
+	if err := SaveFileYamlE(map[string]interface{}{"c": make(chan int)}, path); !errors.Is(err, ErrMarshal) {
+		t.Errorf("unmarshalable value error = %v, want ErrMarshal", err)
+	}
+	if err := SaveFileYamlE(obj, filepath.Join(dir, "missing", "x.yaml")); !errors.Is(err, ErrFileWrite) {
+		t.Errorf("missing directory error = %v, want ErrFileWrite", err)
+	}
+	if !panics(func() { MustSaveFileYaml(obj, filepath.Join(dir, "missing", "x.yaml")) }) {
+		t.Errorf("MustSaveFileYaml did not panic")
+	}
+}
+
+func TestBytesToYamlObjects(t *testing.T) {
+	a, b := map[string]interface{}{"a": 1}, map[string]interface{}{"b": 2}
+	tests := []struct {
+		name string
+		src  string
+		want []interface{}
+	}{
+		{"empty input", "", []interface{}{}},
+		{"only comments", "# nothing\n", []interface{}{}},
+		{"single", "a: 1\n", []interface{}{a}},
+		{"leading separator", "---\na: 1\n", []interface{}{a}},
+		{"two documents", "a: 1\n---\nb: 2\n", []interface{}{a, b}},
+		{"empty document", "---\n", []interface{}{nil}},
+		{"empty document between", "a: 1\n---\n---\nb: 2\n", []interface{}{a, nil, b}},
+		{"trailing separator", "a: 1\n---\n", []interface{}{a, nil}},
+		{"document end markers", "a: 1\n...\n---\nb: 2\n...\n", []interface{}{a, b}},
+		{"scalar after separator", "---\n- 1\n--- x\n", []interface{}{[]interface{}{1}, "x"}},
+	}
+	for _, tt := range tests {
+		got, err := BytesToYamlObjects([]byte(tt.src))
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+	if _, err := BytesToYamlObjects([]byte("a: 1\n---\nb: [\n")); err == nil {
+		t.Errorf("bad second document gave no error")
+	}
+}
+
+func TestSaveFileYamlAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.yaml")
+	objs := []interface{}{
+		map[string]interface{}{"a": 1},
+		nil,
+		[]interface{}{"x", map[string]interface{}{"k": true}},
+		"s",
+	}
+	if err := SaveFileYamlAll(objs, path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := LoadFileText(path)
+	if want := "a: 1\n---\nnull\n---\n- x\n- k: true\n---\ns\n"; string(data) != want {
+		t.Errorf("saved %q, want %q", data, want)
+	}
+	got, err := LoadFileYamlAll(path)
+	if err != nil || !reflect.DeepEqual(got, objs) {
+		t.Errorf("loaded %#v, %v; want %#v", got, err, objs)
+	}
+	if err := SaveFileYamlAll([]interface{}{make(chan int)}, path); !errors.Is(err, ErrMarshal) {
+		t.Errorf("unmarshalable value error = %v, want ErrMarshal", err)
+	}
+	if err := SaveFileYamlAll(nil, path); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadFileYamlAll(path); err != nil || len(got) != 0 {
+		t.Errorf("no documents loaded back as %#v, %v", got, err)
+	}
+}
+
+func TestPrintYamlBytesColorDocuments(t *testing.T) {
+	defer SetColorMode(GetColorMode())
+	SetColorMode(ColorAlways)
+	src := "a: 1\n---\nb: 2\n...\n---\n"
+	var buf bytes.Buffer
+	if err := PrintYamlBytesColor([]byte(src), WithWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	if got := StripANSI(buf.String()); got != src {
+		t.Errorf("uncolored output %q, want %q", got, src)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	for _, i := range []int{1, 3, 4} {
+		if marker := StripANSI(lines[i]); lines[i] == marker {
+			t.Errorf("line %d %q is not colored", i+1, marker)
+		}
+	}
+	if lines[1] != lines[4] {
+		t.Errorf("separators colored differently: %q and %q", lines[1], lines[4])
+	}
+}
@@ -0,0 +1,297 @@
+package utl
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultAliasLimit is the most nodes aliases may expand to when no limit is given
+const DefaultAliasLimit = 10000
+
+// Have the YAML loaders expand aliases and "<<" merge keys themselves, rejecting alias
+// cycles and documents whose aliases expand to more than maxNodes nodes in total, which
+// guards against "billion laughs" style alias bombs. Zero uses DefaultAliasLimit.
+func WithExpandAliases(maxNodes int) LoadOption {
+	return func(o *loadOptions) {
+		o.expand = true
+		o.aliasLimit = maxNodes
+	}
+}
+
+// Returns a copy of given YAML node tree with every alias replaced by a copy of its anchored
+// node, "<<" merge keys resolved into plain keys, and anchors removed. Returns error wrapping
+// ErrAliasCycle if an anchor contains an alias to itself, or ErrAliasLimit if aliases
+// expand to more than maxNodes nodes (zero means DefaultAliasLimit).
+func ExpandYamlNode(node *yaml.Node, maxNodes int) (*yaml.Node, error) {
+	if maxNodes <= 0 {
+		maxNodes = DefaultAliasLimit
+	}
+	e := &aliasExpander{limit: maxNodes, active: map[*yaml.Node]bool{}}
+	return e.expand(node, false)
+}
+
+// Rewrites every document in given YAML stream with aliases and merge keys expanded, as
+// per ExpandYamlNode. Comments are kept. Returns the new YAML bytes and error if any.
+func ExpandYamlAliases(yamlBytes []byte, maxNodes int) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(yamlBytes))
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(guessYamlIndent(yamlBytes))
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, yamlParseError(err, yamlBytes)
+		}
+		expanded, err := ExpandYamlNode(&node, maxNodes)
+		if err != nil {
+			return nil, err
+		}
+		if err = encoder.Encode(expanded); err != nil {
+			return nil, wrapErr(ErrMarshal, err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, wrapErr(ErrMarshal, err)
+	}
+	return buffer.Bytes(), nil
+}
+
+type aliasExpander struct {
+	limit  int
+	count  int
+	active map[*yaml.Node]bool // Nodes currently being expanded, to spot cycles
+}
+
+func (e *aliasExpander) expand(node *yaml.Node, viaAlias bool) (*yaml.Node, error) {
+	if node.Kind == yaml.AliasNode {
+		if node.Alias == nil {
+			return nil, fmt.Errorf("%w: unknown anchor %q", ErrAliasCycle, node.Value)
+		}
+		return e.expand(node.Alias, true)
+	}
+	if e.active[node] {
+		return nil, fmt.Errorf("%w: anchor %q contains itself", ErrAliasCycle, node.Anchor)
+	}
+	if viaAlias {
+		if e.count++; e.count > e.limit {
+			return nil, fmt.Errorf("%w: aliases expand to more than %d nodes", ErrAliasLimit, e.limit)
+		}
+	}
+	e.active[node] = true
+	defer delete(e.active, node)
+
+	copied := *node
+	copied.Anchor = ""
+	copied.Content = nil
+	if node.Kind != yaml.MappingNode {
+		for _, child := range node.Content {
+			c, err := e.expand(child, viaAlias)
+			if err != nil {
+				return nil, err
+			}
+			copied.Content = append(copied.Content, c)
+		}
+		return &copied, nil
+	}
+
+	var merged []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		value, err := e.expand(valueNode, viaAlias)
+		if err != nil {
+			return nil, err
+		}
+		if keyNode.Tag == "!!merge" {
+			switch value.Kind {
+			case yaml.MappingNode:
+				merged = append(merged, value)
+			case yaml.SequenceNode:
+				merged = append(merged, value.Content...)
+			}
+			continue
+		}
+		key, err := e.expand(keyNode, viaAlias)
+		if err != nil {
+			return nil, err
+		}
+		copied.Content = append(copied.Content, key, value)
+	}
+	for _, src := range merged { // Explicit keys win, then earlier merge sources
+		if src.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if !yamlMappingHasKey(&copied, src.Content[i].Value) {
+				copied.Content = append(copied.Content, src.Content[i], src.Content[i+1])
+			}
+		}
+	}
+	return &copied, nil
+}
+
+// Returns true if mapping node has a scalar key with given value. Internal helper function.
+func yamlMappingHasKey(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert YAML interface object to byte slice, with repeated mappings and sequences of at
+// least minNodes nodes written once under an anchor and referenced elsewhere by alias.
+// Handy for large, repetitive configs. Returns YAML bytes and error if any.
+func YamlToBytesDedup(yamlObject interface{}, minNodes int) (yamlBytes []byte, err error) {
+	var node yaml.Node
+	if err = node.Encode(yamlObject); err != nil {
+		return nil, wrapErr(ErrMarshal, err)
+	}
+	DedupYamlNode(&node, minNodes)
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(&node); err != nil {
+		return nil, wrapErr(ErrMarshal, err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// Save given YAML object to given filePath, atomically, deduplicated as per
+// YamlToBytesDedup. Returns error if any.
+func SaveFileYamlDedup(yamlObject interface{}, filePath string, minNodes int, opts ...SaveOption) error {
+	yamlData, err := YamlToBytesDedup(yamlObject, minNodes)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filePath, yamlData, 0600, opts...)
+}
+
+// Rewrites given YAML node tree in place so that the second and later occurrences of any
+// mapping or sequence of at least minNodes nodes become aliases of the first, which gets
+// an anchor named after its key where possible. The inverse of ExpandYamlNode.
+func DedupYamlNode(root *yaml.Node, minNodes int) {
+	d := &yamlDeduper{
+		minNodes: minNodes,
+		hashes:   map[*yaml.Node]uint64{},
+		sizes:    map[*yaml.Node]int{},
+		counts:   map[uint64]int{},
+		first:    map[uint64][]*yaml.Node{},
+		refs:     map[*yaml.Node]int{},
+		names:    map[string]bool{},
+	}
+	d.measure(root)
+	d.rewrite(root, "")
+	for _, candidates := range d.first {
+		for _, anchored := range candidates {
+			if d.refs[anchored] == 0 {
+				anchored.Anchor = "" // Its duplicates all ended up inside other aliases
+			}
+		}
+	}
+}
+
+type yamlDeduper struct {
+	minNodes int
+	hashes   map[*yaml.Node]uint64
+	sizes    map[*yaml.Node]int
+	counts   map[uint64]int
+	first    map[uint64][]*yaml.Node // Anchored nodes by hash, several if hashes collide
+	refs     map[*yaml.Node]int
+	names    map[string]bool
+}
+
+// Computes a structural hash and node count for node and every node below it
+func (d *yamlDeduper) measure(node *yaml.Node) (uint64, int) {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00", node.Kind, node.ShortTag(), node.Value)
+	size := 1
+	for _, child := range node.Content {
+		ch, cs := d.measure(child)
+		fmt.Fprintf(h, "%x,", ch)
+		size += cs
+	}
+	sum := h.Sum64()
+	d.hashes[node], d.sizes[node] = sum, size
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		d.counts[sum]++
+	}
+	return sum, size
+}
+
+// Replaces repeats with aliases, depth first in document order. A hash match is only a
+// candidate: the subtrees are compared in full before one becomes an alias of the other.
+func (d *yamlDeduper) rewrite(node *yaml.Node, key string) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		sum := d.hashes[node]
+		if d.sizes[node] >= d.minNodes && d.counts[sum] > 1 {
+			for _, anchored := range d.first[sum] {
+				if yamlNodesEqual(anchored, node) {
+					d.refs[anchored]++
+					*node = yaml.Node{Kind: yaml.AliasNode, Value: anchored.Anchor, Alias: anchored}
+					return
+				}
+			}
+			node.Anchor = d.anchorName(key)
+			d.first[sum] = append(d.first[sum], node)
+		}
+	}
+	for i, child := range node.Content {
+		childKey := ""
+		if node.Kind == yaml.MappingNode && i%2 == 1 {
+			childKey = node.Content[i-1].Value
+		}
+		d.rewrite(child, childKey)
+	}
+}
+
+// Returns true if YAML node trees a and b have the same kinds, tags and values throughout.
+// Aliases are compared by what they point to, and anchors, comments and styles are ignored.
+// Internal helper function.
+func yamlNodesEqual(a, b *yaml.Node) bool {
+	for a.Kind == yaml.AliasNode && a.Alias != nil {
+		a = a.Alias
+	}
+	for b.Kind == yaml.AliasNode && b.Alias != nil {
+		b = b.Alias
+	}
+	if a == b {
+		return true
+	}
+	if a.Kind != b.Kind || a.ShortTag() != b.ShortTag() || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !yamlNodesEqual(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a unique anchor name based on key, or "anchor" if key isn't usable
+func (d *yamlDeduper) anchorName(key string) string {
+	base := strings.Map(func(r rune) rune {
+		if IsAlpha(r) || IsDigit(r) || r == '_' || r == '-' {
+			return r
+		}
+		return -1
+	}, key)
+	if base == "" {
+		base = "anchor"
+	}
+	name := base
+	for i := 2; d.names[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	d.names[name] = true
+	return name
+}
@@ -0,0 +1,84 @@
+package utl
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestYamlDedupRoundTrip(t *testing.T) {
+	shared := map[string]interface{}{"image": "nginx", "ports": []interface{}{80, 443}}
+	obj := map[string]interface{}{
+		"a": shared,
+		"b": map[string]interface{}{"image": "nginx", "ports": []interface{}{80, 443}},
+		"c": map[string]interface{}{"image": "redis", "ports": []interface{}{80, 443}},
+	}
+	out, err := YamlToBytesDedup(obj, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back interface{}
+	if err = yaml.Unmarshal(out, &back); err != nil {
+		t.Fatalf("%v in:\n%s", err, out)
+	}
+	if !reflect.DeepEqual(back, obj) {
+		t.Errorf("got %v, want %v, from:\n%s", back, obj, out)
+	}
+	var node yaml.Node
+	yaml.Unmarshal(out, &node)
+	aliases := 0
+	var count func(n *yaml.Node)
+	count = func(n *yaml.Node) {
+		if n.Kind == yaml.AliasNode {
+			aliases++
+		}
+		for _, c := range n.Content {
+			count(c)
+		}
+	}
+	count(&node)
+	if aliases == 0 {
+		t.Errorf("no aliases in:\n%s", out)
+	}
+}
+
+func TestYamlDedupHashCollision(t *testing.T) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte("a: [1, 2, 3]\nb: [4, 5, 6]\n"), &root); err != nil {
+		t.Fatal(err)
+	}
+	d := &yamlDeduper{
+		minNodes: 1,
+		hashes:   map[*yaml.Node]uint64{},
+		sizes:    map[*yaml.Node]int{},
+		counts:   map[uint64]int{},
+		first:    map[uint64][]*yaml.Node{},
+		refs:     map[*yaml.Node]int{},
+		names:    map[string]bool{},
+	}
+	d.measure(&root)
+	// Pretend the two different sequences hash the same
+	mapping := root.Content[0]
+	a, b := mapping.Content[1], mapping.Content[3]
+	d.hashes[b] = d.hashes[a]
+	d.counts[d.hashes[a]] = 2
+	d.rewrite(&root, "")
+	if b.Kind == yaml.AliasNode {
+		t.Error("different sequences with the same hash were aliased")
+	}
+}
+
+func TestExpandYamlAliases(t *testing.T) {
+	src := "base: &b {x: 1, y: 2}\nderived:\n  <<: *b\n  y: 3\nlist: [*b]\n"
+	out, err := ExpandYamlAliases([]byte(src), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want interface{}
+	yaml.Unmarshal(out, &got)
+	yaml.Unmarshal([]byte("base: {x: 1, y: 2}\nderived: {x: 1, y: 3}\nlist: [{x: 1, y: 2}]\n"), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,225 @@
+package utl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YamlDoc is a YAML document loaded for editing. Unlike LoadFileYaml, which decodes into
+// plain objects, it keeps the yaml.v3 node tree, so comments, key order, anchors, aliases
+// and quoting style all survive a load, edit and save round trip. Values are addressed
+// by JSON Pointer, same as JsonPointerGet and friends.
+type YamlDoc struct {
+	root   *yaml.Node
+	indent int
+}
+
+// Reads and parses given filePath as a YAML document for editing.
+// Returns the document and error if any.
+func LoadFileYamlDoc(filePath string) (*YamlDoc, error) {
+	fileContent, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, readErr(err)
+	}
+	doc, err := BytesToYamlDoc(fileContent)
+	return doc, withParseSource(err, filePath, nil)
+}
+
+// Parses given byte slice as a YAML document for editing. Only the first document of a
+// multi-document stream is kept. Returns the document and error if any.
+func BytesToYamlDoc(yamlBytes []byte) (*YamlDoc, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(yamlBytes, &root); err != nil {
+		return nil, yamlParseError(err, yamlBytes)
+	}
+	if root.Kind == 0 { // Empty input
+		root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	return &YamlDoc{root: &root, indent: guessYamlIndent(yamlBytes)}, nil
+}
+
+// Returns the underlying yaml.v3 document node, for edits this type doesn't cover
+func (d *YamlDoc) Node() *yaml.Node {
+	return d.root
+}
+
+// Returns the value at given JSON Pointer, decoded into a plain object.
+// Returns error wrapping ErrPathNotFound if there's no such value.
+func (d *YamlDoc) Get(pointer string) (value interface{}, err error) {
+	tokens, err := ParseJsonPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	node, err := yamlNodeAt(d.root.Content[0], tokens, false)
+	if err != nil {
+		return nil, err
+	}
+	if err = node.Decode(&value); err != nil {
+		return nil, yamlParseError(err, nil)
+	}
+	return value, nil
+}
+
+// Sets the value at given JSON Pointer, creating missing intermediate mappings. An existing
+// node keeps its comments and anchor, and a scalar also keeps its quoting style when the
+// new value has the same type. Array index "-" appends. Going through an alias edits the
+// anchored node, and so every other alias of it. Returns error if any.
+func (d *YamlDoc) Set(pointer string, value interface{}) error {
+	tokens, err := ParseJsonPointer(pointer)
+	if err != nil {
+		return err
+	}
+	node, err := yamlNodeAt(d.root.Content[0], tokens, true)
+	if err != nil {
+		return err
+	}
+	var newNode yaml.Node
+	if err = newNode.Encode(value); err != nil {
+		return wrapErr(ErrMarshal, err)
+	}
+	if node.Kind == yaml.ScalarNode && newNode.Kind == yaml.ScalarNode && node.Tag == newNode.Tag {
+		newNode.Style = node.Style
+	}
+	newNode.HeadComment, newNode.LineComment, newNode.FootComment = node.HeadComment, node.LineComment, node.FootComment
+	newNode.Anchor = node.Anchor
+	*node = newNode
+	return nil
+}
+
+// Removes the value at given JSON Pointer, along with its key and comments.
+// Returns error wrapping ErrPathNotFound if there's no such value.
+func (d *YamlDoc) Delete(pointer string) error {
+	tokens, err := ParseJsonPointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		d.root.Content[0] = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		return nil
+	}
+	parent, err := yamlNodeAt(d.root.Content[0], tokens[:len(tokens)-1], false)
+	if err != nil {
+		return err
+	}
+	last := tokens[len(tokens)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(parent.Content); i += 2 {
+			if parent.Content[i].Value == last {
+				parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+				return nil
+			}
+		}
+	case yaml.SequenceNode:
+		idx, err := arrayIndex(last, len(parent.Content), false)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrPathNotFound, pointer, err)
+		}
+		parent.Content = append(parent.Content[:idx], parent.Content[idx+1:]...)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrPathNotFound, pointer)
+}
+
+// Returns the document as YAML bytes, indented like the source was.
+// Returns error if any.
+func (d *YamlDoc) Bytes() ([]byte, error) {
+	untagMergeKeys(d.root)
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(d.indent)
+	if err := encoder.Encode(d.root); err != nil {
+		return nil, wrapErr(ErrMarshal, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, wrapErr(ErrMarshal, err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// Save the document to given filePath, atomically. Returns error if any.
+func (d *YamlDoc) Save(filePath string, opts ...SaveOption) error {
+	yamlData, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filePath, yamlData, 0600, opts...)
+}
+
+// Returns the node at tokens below node, following aliases. If create is true, missing
+// mapping keys and intermediate mappings are added, and "-" or the length of a sequence
+// appends a new element. Internal helper function.
+func yamlNodeAt(node *yaml.Node, tokens []string, create bool) (*yaml.Node, error) {
+	for i, tk := range tokens {
+		for node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		if create && node.Kind == yaml.ScalarNode && node.Tag == "!!null" { // i.e. "key:" with no value
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: node.HeadComment, LineComment: node.LineComment}
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			var child *yaml.Node
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if node.Content[j].Value == tk {
+					child = node.Content[j+1]
+					break
+				}
+			}
+			if child == nil {
+				if !create {
+					return nil, pointerNotFound(tokens[:i+1])
+				}
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: tk}, child)
+			}
+			node = child
+		case yaml.SequenceNode:
+			idx, err := arrayIndex(tk, len(node.Content), create)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrPathNotFound, JsonPointer(tokens[:i+1]...), err)
+			}
+			if idx == len(node.Content) {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+			}
+			node = node.Content[idx]
+		default:
+			return nil, pointerNotFound(tokens[:i+1])
+		}
+	}
+	return node, nil
+}
+
+// Clears the explicit !!merge tag yaml.v3 puts on "<<" keys, which its encoder would
+// otherwise write out verbatim. Internal helper function.
+func untagMergeKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Tag == "!!merge" && key.Value == "<<" {
+				key.Tag = ""
+			}
+		}
+	}
+	for _, child := range node.Content {
+		untagMergeKeys(child)
+	}
+}
+
+// Returns the indentation used by the first nested line of given YAML source, between 2
+// and 8, defaulting to 2. Internal helper function.
+func guessYamlIndent(yamlBytes []byte) int {
+	for _, line := range strings.Split(string(yamlBytes), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		if n := len(line) - len(trimmed); n >= 2 && n <= 8 {
+			return n
+		}
+	}
+	return 2
+}
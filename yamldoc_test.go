@@ -0,0 +1,124 @@
+package utl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const yamlDocSource = `# Service config
+name: web # the name
+zeta: 1
+alpha: 'quoted'
+defaults: &defaults
+  timeout: 30
+  retries: 3
+prod:
+  <<: *defaults
+  timeout: 60
+# Hosts, in order
+hosts:
+  - b.example.com
+  - a.example.com
+`
+
+func TestYamlDocRoundTrip(t *testing.T) {
+	for name, src := range map[string]string{
+		"commented":  yamlDocSource,
+		"4 indent":   "a:\n    b: 1 # one\n    c:\n        - x\n",
+		"flow style": "list: [1, 2]\nmap: {a: b}\n",
+		"empty":      "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			doc, err := BytesToYamlDoc([]byte(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := src
+			if src == "" {
+				want = "{}\n"
+			}
+			if got, err := doc.Bytes(); err != nil || string(got) != want {
+				t.Errorf("got %q, %v, want %q", got, err, want)
+			}
+		})
+	}
+}
+
+func TestYamlDocEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.yaml")
+	if err := os.WriteFile(path, []byte(yamlDocSource), 0600); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := LoadFileYamlDoc(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pointer, value := range map[string]interface{}{
+		"/alpha":            "changed", // Keeps its quotes
+		"/defaults/timeout": 45,        // Through the anchor
+		"/hosts/-":          "c.example.com",
+		"/new/key":          true,
+	} {
+		if err := doc.Set(pointer, value); err != nil {
+			t.Fatalf("Set(%s) error = %v", pointer, err)
+		}
+	}
+	if err := doc.Delete("/zeta"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Service config
+name: web # the name
+alpha: 'changed'
+defaults: &defaults
+  timeout: 45
+  retries: 3
+prod:
+  <<: *defaults
+  timeout: 60
+# Hosts, in order
+hosts:
+  - b.example.com
+  - a.example.com
+  - c.example.com
+new:
+  key: true
+`
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("saved:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestYamlDocGet(t *testing.T) {
+	doc, err := BytesToYamlDoc([]byte(yamlDocSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pointer string
+		want    interface{}
+	}{
+		{"/name", "web"},
+		{"/hosts/1", "a.example.com"},
+		{"/prod/timeout", 60},
+		{"/defaults", map[string]interface{}{"timeout": 30, "retries": 3}},
+	}
+	for _, tt := range tests {
+		if got, err := doc.Get(tt.pointer); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%s) = %v, %v, want %v", tt.pointer, got, err, tt.want)
+		}
+	}
+	for _, pointer := range []string{"/nope", "/hosts/5", "/name/x"} {
+		if _, err := doc.Get(pointer); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("Get(%s) error = %v, want ErrPathNotFound", pointer, err)
+		}
+		if err := doc.Delete(pointer); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("Delete(%s) error = %v, want ErrPathNotFound", pointer, err)
+		}
+	}
+}